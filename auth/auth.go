@@ -0,0 +1,86 @@
+// auth/auth.go
+
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// bearerPrefix starts an Authorization header carrying a token
+const bearerPrefix = "Bearer "
+
+// Middleware identifies the caller from the bearer token in the Authorization header and stores
+// them in the request context for service.CallerFromContext. The token is either a JWT naming a
+// user or an API key. A user's role is read from their user record rather than the token, so role
+// changes apply at once; callers without an active, approved user have no role. Requests without a token
+// stay anonymous, and invalid tokens are rejected. Without an authenticator, as when no JWT key is
+// configured for a local run, JWTs cannot be verified and are rejected as well; API keys still work.
+func Middleware(authenticator *Authenticator, users service.UserService, apiKeys service.APIKeyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !strings.HasPrefix(header, bearerPrefix) {
+				unauthorized(w, "expected a bearer token")
+				return
+			}
+			token := strings.TrimPrefix(header, bearerPrefix)
+			if strings.HasPrefix(token, service.APIKeyPrefix) {
+				apiKey, err := apiKeys.AuthenticateAPIKey(r.Context(), token)
+				if service.ErrorCodeOf(err) == service.CodeUnauthenticated {
+					unauthorized(w, "invalid API key")
+					return
+				}
+				if err != nil {
+					log.Printf("Error authenticating API key: %v", err)
+					http.Error(w, "internal server error", http.StatusInternalServerError)
+					return
+				}
+				caller := &service.Caller{APIKeyID: apiKey.ID, Scopes: apiKey.Scopes}
+				next.ServeHTTP(w, r.WithContext(service.WithCaller(r.Context(), caller)))
+				return
+			}
+
+			if authenticator == nil {
+				unauthorized(w, "JWT authentication is not configured")
+				return
+			}
+			claims, err := authenticator.Parse(token)
+			if err != nil {
+				unauthorized(w, "invalid token")
+				return
+			}
+
+			caller := &service.Caller{DiscordID: claims.Subject, GuildID: claims.GuildID}
+			user, err := users.GetUserByDiscordID(r.Context(), caller.DiscordID, false)
+			switch {
+			case err == nil:
+				// Users awaiting or denied approval hold no role until an Editor approves them
+				caller.Status = user.Status
+				if user.Status == model.ApprovalStatusApproved {
+					caller.Role = user.Role
+				}
+			case !errors.Is(err, service.ErrNotFound):
+				log.Printf("Error identifying caller %s: %v", caller.DiscordID, err)
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(service.WithCaller(r.Context(), caller)))
+		})
+	}
+}
+
+// unauthorized rejects a request whose credentials could not be verified
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// failingUserService fails every user lookup
+type failingUserService struct {
+	service.UserService
+}
+
+func (failingUserService) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	return nil, service.Internal("failed to get user", errors.New("connection refused"))
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator, err := NewAuthenticator(Config{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	client := service.NewMemoryClient()
+	users, apiKeys := service.NewUserService(client), service.NewAPIKeyService(client)
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "adminID", Role: service.RoleAdmin})
+	editor := service.RoleEditor
+	if _, err := users.CreateUser(adminCtx, model.UserInput{DiscordID: "editorID", Name: "Editor", Role: graphql.OmittableOf(&editor)}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	// A self-registered user awaits approval
+	selfCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "pendingID"})
+	if _, err := users.CreateUser(selfCtx, model.UserInput{DiscordID: "pendingID", Name: "Pending"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	scopes := []model.APIKeyScope{model.APIKeyScopeUsersRead}
+	active, err := apiKeys.CreateAPIKey(adminCtx, model.APIKeyInput{Name: "bot", Scopes: scopes})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	revoked, err := apiKeys.CreateAPIKey(adminCtx, model.APIKeyInput{Name: "old bot", Scopes: scopes})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if _, err := apiKeys.RevokeAPIKey(adminCtx, revoked.APIKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		users         service.UserService
+		authorization string
+		wantStatus    int
+		wantCaller    *service.Caller
+	}{
+		{"Anonymous", users, "", http.StatusOK, nil},
+		{"Known_User", users, "Bearer " + signHS256(t, validClaims("editorID")), http.StatusOK, &service.Caller{DiscordID: "editorID", Role: service.RoleEditor, Status: model.ApprovalStatusApproved, GuildID: "guildID"}},
+		{"Pending_User", users, "Bearer " + signHS256(t, validClaims("pendingID")), http.StatusOK, &service.Caller{DiscordID: "pendingID", Status: model.ApprovalStatusPending, GuildID: "guildID"}},
+		{"Unknown_User", users, "Bearer " + signHS256(t, validClaims("newID")), http.StatusOK, &service.Caller{DiscordID: "newID", GuildID: "guildID"}},
+		{"Not_Bearer", users, "Basic dXNlcjpwYXNz", http.StatusUnauthorized, nil},
+		{"Invalid_Token", users, "Bearer not-a-token", http.StatusUnauthorized, nil},
+		{"Lookup_Failure", failingUserService{}, "Bearer " + signHS256(t, validClaims("editorID")), http.StatusInternalServerError, nil},
+		{"API_Key", users, "Bearer " + active.Key, http.StatusOK, &service.Caller{APIKeyID: active.APIKey.ID, Scopes: scopes}},
+		{"Revoked_API_Key", users, "Bearer " + revoked.Key, http.StatusUnauthorized, nil},
+		{"Unknown_API_Key", users, "Bearer " + service.APIKeyPrefix + "unknown", http.StatusUnauthorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *service.Caller
+			handler := Middleware(authenticator, tt.users, apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = service.CallerFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got, tt.wantCaller) {
+				t.Errorf("caller = %+v, want %+v", got, tt.wantCaller)
+			}
+		})
+	}
+}
+
+func signHS256(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestMiddleware_NoAuthenticator(t *testing.T) {
+	client := service.NewMemoryClient()
+	users, apiKeys := service.NewUserService(client), service.NewAPIKeyService(client)
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "adminID", Role: service.RoleAdmin})
+	scopes := []model.APIKeyScope{model.APIKeyScopeUsersRead}
+	key, err := apiKeys.CreateAPIKey(adminCtx, model.APIKeyInput{Name: "bot", Scopes: scopes})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantCaller    *service.Caller
+	}{
+		{"JWT_Rejected", "Bearer " + signHS256(t, validClaims("adminID")), http.StatusUnauthorized, nil},
+		{"API_Key", "Bearer " + key.Key, http.StatusOK, &service.Caller{APIKeyID: key.APIKey.ID, Scopes: scopes}},
+		{"No_Token", "", http.StatusOK, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *service.Caller
+			handler := Middleware(nil, users, apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = service.CallerFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got, tt.wantCaller) {
+				t.Errorf("caller = %+v, want %+v", got, tt.wantCaller)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// auth/jwt.go
+
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// leeway tolerates clock skew between the bot and this service when checking exp and nbf
+const leeway = 30 * time.Second
+
+// ErrNoSigningKey is returned by NewAuthenticator when config holds no key to verify tokens with
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// Claims are the claims of the tokens issued by our bot. The subject is the caller's Discord ID.
+type Claims struct {
+	GuildID string `json:"guild_id,omitempty"` // Discord guild the request was made from
+	jwt.RegisteredClaims
+}
+
+// Config holds the keys tokens may be signed with. At least one of HMACSecret and PublicKey must be set.
+type Config struct {
+	HMACSecret []byte            // Verifies HS256 tokens
+	PublicKey  ed25519.PublicKey // Verifies EdDSA tokens
+	Issuer     string            // Required iss claim; any issuer is accepted if empty
+}
+
+// ConfigFromEnv reads the Config from JWT_HMAC_SECRET, JWT_ED25519_PUBLIC_KEY (base64 encoded) and JWT_ISSUER
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Issuer: os.Getenv("JWT_ISSUER")}
+	if secret := os.Getenv("JWT_HMAC_SECRET"); secret != "" {
+		cfg.HMACSecret = []byte(secret)
+	}
+	if encoded := os.Getenv("JWT_ED25519_PUBLIC_KEY"); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to decode JWT_ED25519_PUBLIC_KEY: %w", err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return Config{}, fmt.Errorf("JWT_ED25519_PUBLIC_KEY must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+		}
+		cfg.PublicKey = ed25519.PublicKey(key)
+	}
+	return cfg, nil
+}
+
+// Authenticator validates the bearer tokens issued by our bot
+type Authenticator struct {
+	config Config
+	parser *jwt.Parser
+}
+
+// NewAuthenticator creates an Authenticator accepting tokens signed with any key in config
+func NewAuthenticator(config Config) (*Authenticator, error) {
+	var methods []string
+	if len(config.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(config.PublicKey) > 0 {
+		methods = append(methods, jwt.SigningMethodEdDSA.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, ErrNoSigningKey
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	return &Authenticator{config: config, parser: jwt.NewParser(options...)}, nil
+}
+
+// Parse verifies token's signature, expiry and issuer and returns its claims
+func (a *Authenticator) Parse(token string) (*Claims, error) {
+	var claims Claims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.key); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return &claims, nil
+}
+
+// key returns the key verifying token, chosen by its signing method
+func (a *Authenticator) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.config.HMACSecret, nil
+	case *jwt.SigningMethodEd25519:
+		return a.config.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte("test-secret")
+
+// validClaims returns unexpired claims from the test issuer for discordID
+func validClaims(discordID string) Claims {
+	return Claims{
+		GuildID: "guildID",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   discordID,
+			Issuer:    "tcr-bot",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestAuthenticator_Parse(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	authenticator, err := NewAuthenticator(Config{HMACSecret: testSecret, PublicKey: publicKey, Issuer: "tcr-bot"})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, mutate func(*Claims)) string {
+		claims := validClaims("12345")
+		if mutate != nil {
+			mutate(&claims)
+		}
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"HS256", sign(jwt.SigningMethodHS256, testSecret, nil), false},
+		{"EdDSA", sign(jwt.SigningMethodEdDSA, privateKey, nil), false},
+		{"Wrong_Secret", sign(jwt.SigningMethodHS256, []byte("other-secret"), nil), true},
+		{"Wrong_Key", sign(jwt.SigningMethodEdDSA, otherKey, nil), true},
+		{"Unsupported_Method", sign(jwt.SigningMethodHS512, testSecret, nil), true},
+		{"Unsigned", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, nil), true},
+		{"Expired", sign(jwt.SigningMethodHS256, testSecret, func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		}), true},
+		{"No_Expiry", sign(jwt.SigningMethodHS256, testSecret, func(c *Claims) { c.ExpiresAt = nil }), true},
+		{"Wrong_Issuer", sign(jwt.SigningMethodHS256, testSecret, func(c *Claims) { c.Issuer = "someone-else" }), true},
+		{"No_Subject", sign(jwt.SigningMethodHS256, testSecret, func(c *Claims) { c.Subject = "" }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := authenticator.Parse(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (claims.Subject != "12345" || claims.GuildID != "guildID") {
+				t.Errorf("Parse() claims = %+v, want subject 12345 in guild guildID", claims)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_SingleMethod(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	authenticator, err := NewAuthenticator(Config{PublicKey: publicKey})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	// With no secret configured, an HS256 token signed with an empty key must not verify
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims("12345")).SignedString([]byte{})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := authenticator.Parse(hs256); err == nil {
+		t.Error("Parse() accepted an HS256 token without a configured secret")
+	}
+	eddsa, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, validClaims("12345")).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	if _, err := authenticator.Parse(eddsa); err != nil {
+		t.Errorf("Parse() error = %v", err)
+	}
+
+	if _, err := NewAuthenticator(Config{}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("NewAuthenticator() without keys error = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	t.Setenv("JWT_HMAC_SECRET", "secret")
+	t.Setenv("JWT_ED25519_PUBLIC_KEY", base64.StdEncoding.EncodeToString(publicKey))
+	t.Setenv("JWT_ISSUER", "tcr-bot")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	if string(cfg.HMACSecret) != "secret" || !publicKey.Equal(cfg.PublicKey) || cfg.Issuer != "tcr-bot" {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	t.Setenv("JWT_ED25519_PUBLIC_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() accepted a truncated public key")
+	}
+}
@@ -0,0 +1,111 @@
+// discord/commands.go
+
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// runCommand dispatches an application command as the user who invoked it
+func (h *Handler) runCommand(ctx context.Context, in *interaction) response {
+	invoker := in.invoker()
+	ctx = service.WithCaller(ctx, &service.Caller{DiscordID: invoker.ID, GuildID: in.GuildID})
+
+	switch in.Data.Name {
+	case "register":
+		return h.register(ctx, in, invoker)
+	case "whois":
+		return h.whois(ctx, targetID(in, invoker))
+	case "tag":
+		return h.tag(ctx, targetID(in, invoker))
+	default:
+		return reply(fmt.Sprintf("Unknown command /%s.", in.Data.Name))
+	}
+}
+
+// register creates a user for the invoker, named by the name option or their Discord name
+func (h *Handler) register(ctx context.Context, in *interaction, invoker *user) response {
+	name := in.Data.option("name")
+	if name == "" {
+		name = displayName(in, invoker)
+	}
+
+	created, err := h.Users.CreateUser(ctx, model.UserInput{DiscordID: invoker.ID, Name: name})
+	if err == nil && created.Status == model.ApprovalStatusPending {
+		return reply(fmt.Sprintf("Registered as %s. An Editor will review your sign-up.", created.Name))
+	}
+	if err == nil {
+		return reply(fmt.Sprintf("Registered as %s.", created.Name))
+	}
+	switch service.ErrorCodeOf(err) {
+	case service.CodeAlreadyExists:
+		return reply("You are already registered.")
+	case service.CodeInvalidInput:
+		return reply(err.Error())
+	}
+	return failed("register", err)
+}
+
+// whois describes the registered user with the given Discord ID
+func (h *Handler) whois(ctx context.Context, discordID string) response {
+	found, err := h.Users.GetUserByDiscordID(ctx, discordID, false)
+	if errors.Is(err, service.ErrNotFound) {
+		return reply(fmt.Sprintf("<@%s> is not registered.", discordID))
+	}
+	if err != nil {
+		return failed("whois", err)
+	}
+
+	tag := "no tag"
+	if found.TagNumber != nil {
+		tag = fmt.Sprintf("tag #%d", *found.TagNumber)
+	}
+	return reply(fmt.Sprintf("<@%s> is %s (%s, %s).", discordID, found.Name, found.Role, tag))
+}
+
+// tag reports the tag held by the registered user with the given Discord ID
+func (h *Handler) tag(ctx context.Context, discordID string) response {
+	found, err := h.Users.GetUserByDiscordID(ctx, discordID, false)
+	if errors.Is(err, service.ErrNotFound) {
+		return reply(fmt.Sprintf("<@%s> is not registered.", discordID))
+	}
+	if err != nil {
+		return failed("tag", err)
+	}
+
+	if found.TagNumber == nil {
+		return reply(fmt.Sprintf("<@%s> has no tag.", discordID))
+	}
+	return reply(fmt.Sprintf("<@%s> holds tag #%d.", discordID, *found.TagNumber))
+}
+
+// targetID returns the user named by the user option, defaulting to the invoker
+func targetID(in *interaction, invoker *user) string {
+	if id := in.Data.option("user"); id != "" {
+		return id
+	}
+	return invoker.ID
+}
+
+// displayName returns the name the invoker goes by: their guild nickname, global name or username
+func displayName(in *interaction, invoker *user) string {
+	switch {
+	case in.Member != nil && in.Member.Nick != "":
+		return in.Member.Nick
+	case invoker.GlobalName != "":
+		return invoker.GlobalName
+	}
+	return invoker.Username
+}
+
+// failed logs an unexpected error and tells the user the command failed
+func failed(command string, err error) response {
+	log.Printf("Error running /%s: %v", command, err)
+	return reply("Something went wrong, please try again later.")
+}
@@ -0,0 +1,198 @@
+// discord/interactions.go
+
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// maxBodyBytes caps the size of an interaction payload
+const maxBodyBytes = 1 << 20
+
+// maxSignatureAge is how far a request's signature timestamp may be from now, so a captured
+// request cannot be replayed later
+const maxSignatureAge = 5 * time.Minute
+
+// Interaction types sent by Discord
+const (
+	interactionPing               = 1
+	interactionApplicationCommand = 2
+)
+
+// Interaction response types
+const (
+	responsePong                     = 1
+	responseChannelMessageWithSource = 4
+)
+
+// flagEphemeral shows a message only to the user who ran the command
+const flagEphemeral = 1 << 6
+
+// interaction is the subset of a Discord interaction payload the handler reads
+type interaction struct {
+	Type    int          `json:"type"`
+	GuildID string       `json:"guild_id"`
+	Member  *member      `json:"member"` // Set in guilds
+	User    *user        `json:"user"`   // Set in DMs
+	Data    *commandData `json:"data"`
+}
+
+type member struct {
+	User *user  `json:"user"`
+	Nick string `json:"nick"`
+}
+
+type user struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+type commandData struct {
+	Name    string          `json:"name"`
+	Options []commandOption `json:"options"`
+}
+
+type commandOption struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// invoker returns the user who triggered the interaction, whether in a guild or a DM
+func (i *interaction) invoker() *user {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// option returns the value of the named string, user or integer option, or "" if it was not given
+func (d *commandData) option(name string) string {
+	for _, opt := range d.Options {
+		if opt.Name != name {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(opt.Value, &s); err == nil {
+			return s
+		}
+		return string(opt.Value)
+	}
+	return ""
+}
+
+// response is an interaction response
+type response struct {
+	Type int           `json:"type"`
+	Data *responseData `json:"data,omitempty"`
+}
+
+type responseData struct {
+	Content         string           `json:"content"`
+	Flags           int              `json:"flags,omitempty"`
+	AllowedMentions *allowedMentions `json:"allowed_mentions,omitempty"`
+}
+
+// allowedMentions with no parse entries keeps replies from pinging the users they mention
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+// reply builds an ephemeral message response
+func reply(content string) response {
+	return response{
+		Type: responseChannelMessageWithSource,
+		Data: &responseData{Content: content, Flags: flagEphemeral, AllowedMentions: &allowedMentions{Parse: []string{}}},
+	}
+}
+
+// Handler answers Discord interactions posted to the application's interactions endpoint URL
+type Handler struct {
+	PublicKey ed25519.PublicKey
+	Users     service.UserService
+}
+
+// NewHandler creates a Handler verifying requests with the application's public key
+func NewHandler(publicKey ed25519.PublicKey, users service.UserService) *Handler {
+	return &Handler{PublicKey: publicKey, Users: users}
+}
+
+// ParsePublicKey decodes the hex-encoded public key shown in the Discord developer portal
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ServeHTTP verifies the request signature, then answers PINGs and application commands.
+// Discord requires unsigned or badly signed requests to be rejected with 401.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !h.verify(r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var in interaction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	switch in.Type {
+	case interactionPing:
+		writeResponse(w, response{Type: responsePong})
+	case interactionApplicationCommand:
+		if in.Data == nil || in.invoker() == nil {
+			http.Error(w, "invalid interaction", http.StatusBadRequest)
+			return
+		}
+		writeResponse(w, h.runCommand(r.Context(), &in))
+	default:
+		http.Error(w, "unsupported interaction type", http.StatusBadRequest)
+	}
+}
+
+// verify reports whether signature is the application's signature of timestamp followed by body,
+// and timestamp is a Unix time within maxSignatureAge of now
+func (h *Handler) verify(signature, timestamp string, body []byte) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return false
+	}
+	return ed25519.Verify(h.PublicKey, append([]byte(timestamp), body...), sig)
+}
+
+// writeResponse writes resp as JSON
+func writeResponse(w http.ResponseWriter, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error writing interaction response: %v", err)
+	}
+}
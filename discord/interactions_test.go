@@ -0,0 +1,159 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// newTestHandler returns a Handler backed by an empty in-memory store and the key signing its requests
+func newTestHandler(t *testing.T) (*Handler, ed25519.PrivateKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return NewHandler(publicKey, service.NewUserService(service.NewMemoryClient())), privateKey
+}
+
+// post sends body to h signed with key just now and returns the recorded response
+func post(h *Handler, key ed25519.PrivateKey, body string) *httptest.ResponseRecorder {
+	return postAt(h, key, time.Now(), body)
+}
+
+// postAt sends body to h signed with key at the given time and returns the recorded response
+func postAt(h *Handler, key ed25519.PrivateKey, signedAt time.Time, body string) *httptest.ResponseRecorder {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// command builds an application command invoked in a guild by the user with the given ID
+func command(invokerID, name, options string) string {
+	return `{"type":2,"guild_id":"guildID","member":{"user":{"id":"` + invokerID + `","username":"player","global_name":"Player One"}},` +
+		`"data":{"name":"` + name + `","options":[` + options + `]}}`
+}
+
+// decode reads the interaction response in rec
+func decode(t *testing.T, rec *httptest.ResponseRecorder) response {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var resp response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestHandler_Verification(t *testing.T) {
+	h, key := newTestHandler(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	if resp := decode(t, post(h, key, `{"type":1}`)); resp.Type != responsePong {
+		t.Errorf("PING response type = %d, want %d", resp.Type, responsePong)
+	}
+	if rec := post(h, otherKey, `{"type":1}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(`{"type":1}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// A correctly signed request is rejected once its timestamp is stale, so it cannot be replayed
+	for _, signedAt := range []time.Time{time.Now().Add(-10 * time.Minute), time.Now().Add(10 * time.Minute)} {
+		if rec := postAt(h, key, signedAt, `{"type":1}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("timestamp %s status = %d, want %d", signedAt, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if rec := postAt(h, key, time.Now().Add(-time.Minute), `{"type":1}`); rec.Code != http.StatusOK {
+		t.Errorf("minute-old timestamp status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if rec := post(h, key, `{"type":99}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown type status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_Commands(t *testing.T) {
+	h, key := newTestHandler(t)
+
+	tests := []struct {
+		name        string
+		body        string
+		wantContent string
+	}{
+		{"Whois_Unregistered", command("111", "whois", ""), "<@111> is not registered."},
+		{"Register_Display_Name", command("111", "register", ""), "Registered as Player One. An Editor will review your sign-up."},
+		{"Register_Twice", command("111", "register", ""), "You are already registered."},
+		{"Register_Name_Option", command("222", "register", `{"name":"name","type":3,"value":"Disc Golfer"}`), "Registered as Disc Golfer. An Editor will review your sign-up."},
+		{"Whois_Other_User", command("111", "whois", `{"name":"user","type":6,"value":"222"}`), "<@222> is Disc Golfer (Rattler, no tag)."},
+		{"Tag_Self", command("111", "tag", ""), "<@111> has no tag."},
+		{"Unknown_Command", command("111", "bogus", ""), "Unknown command /bogus."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decode(t, post(h, key, tt.body))
+			if resp.Type != responseChannelMessageWithSource || resp.Data == nil {
+				t.Fatalf("response = %+v, want a channel message", resp)
+			}
+			if resp.Data.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", resp.Data.Content, tt.wantContent)
+			}
+			if resp.Data.Flags != flagEphemeral {
+				t.Errorf("flags = %d, want ephemeral", resp.Data.Flags)
+			}
+		})
+	}
+
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+	if _, err := h.Users.ApproveUser(editorCtx, "111"); err != nil {
+		t.Fatalf("ApproveUser() error = %v", err)
+	}
+	if _, err := h.Users.ClaimTag(editorCtx, "111", 7); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if resp := decode(t, post(h, key, command("222", "tag", `{"name":"user","type":6,"value":"111"}`))); resp.Data.Content != "<@111> holds tag #7." {
+		t.Errorf("tag content = %q, want <@111> holds tag #7.", resp.Data.Content)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	got, err := ParsePublicKey(hex.EncodeToString(publicKey))
+	if err != nil || !publicKey.Equal(got) {
+		t.Errorf("ParsePublicKey() = %x, %v, want %x", got, err, publicKey)
+	}
+	if _, err := ParsePublicKey("abcd"); err == nil {
+		t.Error("ParsePublicKey() accepted a short key")
+	}
+	if _, err := ParsePublicKey("not hex"); err == nil {
+		t.Error("ParsePublicKey() accepted invalid hex")
+	}
+}
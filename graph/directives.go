@@ -0,0 +1,65 @@
+// graph/directives.go
+
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// Directives returns the implementations of the schema's directives
+func Directives() DirectiveRoot {
+	return DirectiveRoot{HasRole: HasRole, HasScope: HasScope}
+}
+
+// HasRole implements @hasRole: the caller must hold at least role, unless orSelf is set and the
+// field acts on the caller's own user, which users awaiting approval may not do. API key clients
+// must instead hold scope.
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role model.Role, orSelf bool, scope *model.APIKeyScope) (interface{}, error) {
+	caller := service.CallerFromContext(ctx)
+	if caller == nil {
+		return nil, service.Unauthenticatedf("authentication required")
+	}
+	if caller.IsAPIKey() {
+		if scope == nil {
+			return nil, service.Forbiddenf("not available to API keys")
+		}
+		return HasScope(ctx, obj, next, *scope)
+	}
+	if caller.HasRole(role) {
+		return next(ctx)
+	}
+	if orSelf && caller.CanActOnSelf() && targetDiscordID(ctx) == caller.DiscordID {
+		return next(ctx)
+	}
+	return nil, service.Forbiddenf("requires the %s role", role)
+}
+
+// HasScope implements @hasScope: the caller must be authenticated, and API key clients must also
+// hold scope. Signed-in users pass whatever their role.
+func HasScope(ctx context.Context, obj interface{}, next graphql.Resolver, scope model.APIKeyScope) (interface{}, error) {
+	caller := service.CallerFromContext(ctx)
+	if caller == nil {
+		return nil, service.Unauthenticatedf("authentication required")
+	}
+	if caller.IsAPIKey() && !caller.HasScope(scope) {
+		return nil, service.Forbiddenf("API key lacks the %s scope", scope)
+	}
+	return next(ctx)
+}
+
+// targetDiscordID returns the Discord ID of the user the current field acts on, taken from its
+// discordID argument or its input's discordID, or "" if it names none
+func targetDiscordID(ctx context.Context) string {
+	args := graphql.GetFieldContext(ctx).Args
+	if id, ok := args["discordID"].(string); ok {
+		return id
+	}
+	if input, ok := args["input"].(model.UserInput); ok {
+		return input.DiscordID
+	}
+	return ""
+}
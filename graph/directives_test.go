@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/mocks"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+func TestHasRole(t *testing.T) {
+	player := &service.Caller{DiscordID: "playerID", Role: service.RoleRattler, Status: model.ApprovalStatusApproved}
+	editor := &service.Caller{DiscordID: "editorID", Role: service.RoleEditor}
+	admin := &service.Caller{DiscordID: "adminID", Role: service.RoleAdmin}
+	newcomer := &service.Caller{DiscordID: "newID"}
+	pending := &service.Caller{DiscordID: "pendingID", Status: model.ApprovalStatusPending}
+	rejected := &service.Caller{DiscordID: "rejectedID", Status: model.ApprovalStatusRejected}
+	bot := &service.Caller{APIKeyID: "keyID", Scopes: []model.APIKeyScope{model.APIKeyScopeUsersWrite}}
+	usersWrite, tagsWrite := model.APIKeyScopeUsersWrite, model.APIKeyScopeTagsWrite
+
+	tests := []struct {
+		name     string
+		caller   *service.Caller
+		args     map[string]interface{}
+		role     model.Role
+		orSelf   bool
+		scope    *model.APIKeyScope
+		wantCode service.ErrorCode
+	}{
+		{"Anonymous", nil, map[string]interface{}{"discordID": "playerID"}, model.RoleEditor, true, nil, service.CodeUnauthenticated},
+		{"Self", player, map[string]interface{}{"discordID": "playerID"}, model.RoleEditor, true, nil, ""},
+		{"Self_Input", newcomer, map[string]interface{}{"input": model.UserInput{DiscordID: "newID"}}, model.RoleEditor, true, nil, ""},
+		{"Pending_Self", pending, map[string]interface{}{"discordID": "pendingID"}, model.RoleEditor, true, nil, service.CodeForbidden},
+		{"Rejected_Self", rejected, map[string]interface{}{"discordID": "rejectedID"}, model.RoleEditor, true, nil, service.CodeForbidden},
+		{"Self_Not_Allowed", player, map[string]interface{}{"discordID": "playerID"}, model.RoleEditor, false, nil, service.CodeForbidden},
+		{"Other_User", player, map[string]interface{}{"discordID": "otherID"}, model.RoleEditor, true, nil, service.CodeForbidden},
+		{"Editor", editor, map[string]interface{}{"discordID": "otherID"}, model.RoleEditor, true, nil, ""},
+		{"Editor_Below_Admin", editor, map[string]interface{}{"discordID": "otherID"}, model.RoleAdmin, false, nil, service.CodeForbidden},
+		{"Admin_Above_Editor", admin, map[string]interface{}{"discordID": "otherID"}, model.RoleEditor, false, nil, ""},
+		{"API_Key_With_Scope", bot, map[string]interface{}{"discordID": "otherID"}, model.RoleEditor, true, &usersWrite, ""},
+		{"API_Key_Without_Scope", bot, map[string]interface{}{"discordID": "otherID"}, model.RoleEditor, true, &tagsWrite, service.CodeForbidden},
+		{"API_Key_Not_Allowed", bot, map[string]interface{}{"discordID": "otherID"}, model.RoleAdmin, false, nil, service.CodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{Args: tt.args})
+			if tt.caller != nil {
+				ctx = service.WithCaller(ctx, tt.caller)
+			}
+
+			called := false
+			next := func(ctx context.Context) (interface{}, error) {
+				called = true
+				return "ok", nil
+			}
+			_, err := HasRole(ctx, nil, next, tt.role, tt.orSelf, tt.scope)
+
+			if tt.wantCode == "" {
+				if err != nil || !called {
+					t.Errorf("HasRole() error = %v, called = %v, want resolver called", err, called)
+				}
+				return
+			}
+			var svcErr *service.Error
+			if !errors.As(err, &svcErr) || svcErr.Code != tt.wantCode {
+				t.Errorf("HasRole() error = %v, want code %v", err, tt.wantCode)
+			}
+			if called {
+				t.Error("HasRole() called the resolver for an unauthorized caller")
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	bot := &service.Caller{APIKeyID: "keyID", Scopes: []model.APIKeyScope{model.APIKeyScopeUsersRead}}
+	player := &service.Caller{DiscordID: "playerID", Role: service.RoleRattler}
+
+	tests := []struct {
+		name     string
+		caller   *service.Caller
+		scope    model.APIKeyScope
+		wantCode service.ErrorCode
+	}{
+		{"Anonymous", nil, model.APIKeyScopeUsersRead, service.CodeUnauthenticated},
+		{"User", player, model.APIKeyScopeTagsRead, ""},
+		{"API_Key_With_Scope", bot, model.APIKeyScopeUsersRead, ""},
+		{"API_Key_Without_Scope", bot, model.APIKeyScopeTagsRead, service.CodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.caller != nil {
+				ctx = service.WithCaller(ctx, tt.caller)
+			}
+			next := func(ctx context.Context) (interface{}, error) { return "ok", nil }
+			_, err := HasScope(ctx, nil, next, tt.scope)
+			if (err != nil) != (tt.wantCode != "") {
+				t.Fatalf("HasScope() error = %v, want code %q", err, tt.wantCode)
+			}
+			if code := service.ErrorCodeOf(err); err != nil && code != tt.wantCode {
+				t.Errorf("HasScope() code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHasScope_Anonymous(t *testing.T) {
+	mockPGClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock PG client: %v", err)
+	}
+	defer mockPGClient.Close(context.Background())
+
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{UserService: &mocks.MockUserService{PGClientMock: mockPGClient}},
+		Directives: Directives(),
+	}))
+	c := client.New(srv)
+
+	// Read scopes would mean nothing if dropping the Authorization header skipped them
+	for _, query := range []string{
+		`{ getUser(discordID: "validID") { discordID } }`,
+		`{ searchUsers(query: "Test") { score } }`,
+		`{ tagHistory(tagNumber: 1) { tagNumber } }`,
+	} {
+		var resp map[string]any
+		if err := c.Post(query, &resp); err == nil || !strings.Contains(err.Error(), "authentication required") {
+			t.Errorf("%s anonymous error = %v, want authentication required", query, err)
+		}
+	}
+}
+
+func TestHasScope_UserTagHistory(t *testing.T) {
+	mockPGClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock PG client: %v", err)
+	}
+	defer mockPGClient.Close(context.Background())
+
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{UserService: &mocks.MockUserService{PGClientMock: mockPGClient}},
+		Directives: Directives(),
+	}))
+	query := `{ getUser(discordID: "validID") { discordID tagHistory { tagNumber } } }`
+
+	tests := []struct {
+		name    string
+		scopes  []model.APIKeyScope
+		wantErr bool
+	}{
+		{"Users_Read_Only", []model.APIKeyScope{model.APIKeyScopeUsersRead}, true},
+		{"Users_And_Tags_Read", []model.APIKeyScope{model.APIKeyScopeUsersRead, model.APIKeyScopeTagsRead}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := &service.Caller{APIKeyID: "keyID", Scopes: tt.scopes}
+			c := client.New(srv, func(r *client.Request) {
+				r.HTTP = r.HTTP.WithContext(service.WithCaller(r.HTTP.Context(), bot))
+			})
+
+			var resp map[string]any
+			err := c.Post(query, &resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getUser { tagHistory } error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "TAGS_READ") {
+				t.Errorf("getUser { tagHistory } error = %v, want missing TAGS_READ scope", err)
+			}
+		})
+	}
+}
@@ -4,19 +4,25 @@ package graph
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/loaders"
 )
 
-// FindUser ByDiscordID is the resolver for the findUser ByDiscordID field.
+// FindUserByDiscordID is the resolver for the findUserByDiscordID field.
 func (r *entityResolver) FindUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
-	// Call the UserService's GetUser ByDiscordID method to retrieve the user
-	user, err := r.UserService.GetUserByDiscordID(ctx, discordID) // Updated method name
-	if err != nil {
-		return nil, fmt.Errorf("failed to find user with Discord ID %s: %v", discordID, err)
+	// The gateway's representations are resolved concurrently, so the request's loader fetches
+	// them in one batch, sharing its cache with other lookups
+	return entityUser(loaders.LoadUser(ctx, r.UserService, discordID))
+}
+
+// FindUserByTagNumber is the resolver for the findUserByTagNumber field.
+func (r *entityResolver) FindUserByTagNumber(ctx context.Context, tagNumber *int) (*model.User, error) {
+	// A null tag names no user
+	if tagNumber == nil {
+		return nil, nil
 	}
-	return user, nil
+	return entityUser(loaders.LoadUserByTagNumber(ctx, r.UserService, *tagNumber))
 }
 
 // Entity returns EntityResolver implementation.
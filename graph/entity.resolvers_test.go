@@ -3,10 +3,15 @@ package graph
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/loaders"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/mocks" // Ensure this import is present
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 )
 
 func TestEntityResolver_FindUserByDiscordID(t *testing.T) {
@@ -16,37 +21,163 @@ func TestEntityResolver_FindUserByDiscordID(t *testing.T) {
 	}
 	defer mockPGClient.Close(context.Background()) // Ensure to close the mock client after the test
 
-	// Set up the mock user service
-	mockUserService := &MockUserService{
-		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string) (*model.User, error) {
-			if discordID == "validID" {
-				return &model.User{DiscordID: discordID, Name: "Test User"}, nil
+	entityResolver := &entityResolver{&Resolver{UserService: &mocks.MockUserService{PGClientMock: mockPGClient}}}
+
+	// Missing, deleted and pending users resolve to null rather than an error
+	tests := []struct {
+		discordID string
+		want      bool
+	}{
+		{"validID", true},
+		{"invalidID", false},
+		{"deletedID", false},
+		{"pendingID", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.discordID, func(t *testing.T) {
+			got, err := entityResolver.FindUserByDiscordID(context.Background(), tt.discordID)
+			if err != nil {
+				t.Fatalf("FindUserByDiscordID() error = %v", err)
 			}
-			return nil, errors.New("user not found")
+			if (got != nil) != tt.want {
+				t.Errorf("FindUserByDiscordID() = %v, want found %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntityResolver_FindUserByDiscordID_Error(t *testing.T) {
+	mockUserService := &MockUserService{
+		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+			return nil, service.Internal("failed to retrieve user", errors.New("connection refused"))
+		},
+	}
+
+	entityResolver := &entityResolver{&Resolver{UserService: mockUserService}}
+	if _, err := entityResolver.FindUserByDiscordID(context.Background(), "validID"); service.ErrorCodeOf(err) != service.CodeInternal {
+		t.Errorf("FindUserByDiscordID() error = %v, want INTERNAL", err)
+	}
+}
+
+func TestEntityResolver_FindUserByTagNumber(t *testing.T) {
+	mockPGClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock PG client: %v", err)
+	}
+	defer mockPGClient.Close(context.Background())
+
+	entityResolver := &entityResolver{&Resolver{UserService: &mocks.MockUserService{PGClientMock: mockPGClient}}}
+
+	one, two := 1, 2
+	for _, tagNumber := range []*int{&two, nil} {
+		if got, err := entityResolver.FindUserByTagNumber(context.Background(), tagNumber); got != nil || err != nil {
+			t.Errorf("FindUserByTagNumber(%v) = %v, %v, want nil, nil", tagNumber, got, err)
+		}
+	}
+	got, err := entityResolver.FindUserByTagNumber(context.Background(), &one)
+	if err != nil || got == nil || got.DiscordID != "validID" {
+		t.Errorf("FindUserByTagNumber(1) = %v, %v, want validID", got, err)
+	}
+}
+
+func TestEntities_MixedKeys(t *testing.T) {
+	mockPGClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock PG client: %v", err)
+	}
+	defer mockPGClient.Close(context.Background())
+
+	// Count lookups to check that each key's representations are still fetched in one batch
+	var mu sync.Mutex
+	discordIDCalls, tagCalls := 0, 0
+	mockUserService := &MockUserService{
+		GetUsersByDiscordIDsFunc: func(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+			mu.Lock()
+			discordIDCalls++
+			mu.Unlock()
+			return mockPGClient.GetUsersByDiscordIDs(ctx, discordIDs)
+		},
+		GetUsersByTagNumbersFunc: func(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+			mu.Lock()
+			tagCalls++
+			mu.Unlock()
+			return mockPGClient.GetUsersByTagNumbers(ctx, tagNumbers)
 		},
 	}
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: &Resolver{UserService: mockUserService}}))
+	c := client.New(loaders.Middleware(mockUserService)(srv))
 
-	resolver := &Resolver{UserService: mockUserService}
-	entityResolver := &entityResolver{resolver}
+	query := `query($representations: [_Any!]!) { _entities(representations: $representations) { ... on User { discordID } } }`
+	representations := []map[string]any{
+		{"__typename": "User", "discordID": "validID"},
+		{"__typename": "User", "tagNumber": 1},
+		{"__typename": "User", "tagNumber": 2},
+		{"__typename": "User", "discordID": "pendingID"},
+		{"__typename": "User", "discordID": "invalidID"},
+	}
+	var resp struct {
+		Entities []*struct{ DiscordID string } `json:"_entities"`
+	}
+	if err := c.Post(query, &resp, client.Var("representations", representations)); err != nil {
+		t.Fatalf("_entities error = %v", err)
+	}
 
+	want := []string{"validID", "validID", "", "", ""}
+	if len(resp.Entities) != len(want) {
+		t.Fatalf("_entities returned %d entities, want %d", len(resp.Entities), len(want))
+	}
+	for i, entity := range resp.Entities {
+		got := ""
+		if entity != nil {
+			got = entity.DiscordID
+		}
+		if got != want[i] {
+			t.Errorf("_entities[%d] = %q, want %q", i, got, want[i])
+		}
+	}
+	if discordIDCalls != 1 || tagCalls != 1 {
+		t.Errorf("_entities made %d Discord ID and %d tag lookups, want 1 each", discordIDCalls, tagCalls)
+	}
+}
+
+func TestEntities_RoutesByKey(t *testing.T) {
+	mockPGClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock PG client: %v", err)
+	}
+	defer mockPGClient.Close(context.Background())
+
+	srv := handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: &Resolver{
+		UserService: &mocks.MockUserService{PGClientMock: mockPGClient},
+	}}))
+	c := client.New(srv)
+
+	query := `query($representations: [_Any!]!) { _entities(representations: $representations) { ... on User { discordID } } }`
 	tests := []struct {
-		discordID string
-		want      *model.User
-		wantErr   bool
+		name           string
+		representation map[string]any
+		want           any
 	}{
-		{"validID", &model.User{DiscordID: "validID", Name: "Test User"}, false},
-		{"invalidID", nil, true},
+		{name: "DiscordID", representation: map[string]any{"__typename": "User", "discordID": "validID"}, want: "validID"},
+		{name: "TagNumber", representation: map[string]any{"__typename": "User", "tagNumber": 1}, want: "validID"},
+		{name: "Unclaimed_Tag", representation: map[string]any{"__typename": "User", "tagNumber": 2}, want: nil},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.discordID, func(t *testing.T) {
-			got, err := entityResolver.FindUserByDiscordID(context.Background(), tt.discordID)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("FindUser ByDiscordID() error = %v, wantErr %v", err, tt.wantErr)
-				return
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				Entities []*struct{ DiscordID string } `json:"_entities"`
+			}
+			err := c.Post(query, &resp, client.Var("representations", []map[string]any{tt.representation}))
+			if err != nil {
+				t.Fatalf("_entities error = %v", err)
+			}
+			var got any
+			if resp.Entities[0] != nil {
+				got = resp.Entities[0].DiscordID
 			}
-			if !tt.wantErr && got.DiscordID != tt.want.DiscordID {
-				t.Errorf("FindUser ByDiscordID() = %v, want %v", got, tt.want)
+			if got != tt.want {
+				t.Errorf("_entities = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,46 @@
+// graph/errors.go
+
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// internalErrorMessage replaces the message of any error that is not safe to show to clients
+const internalErrorMessage = "internal server error"
+
+// ErrorPresenter maps service errors to GraphQL errors with extensions.code, and masks internal failures
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	var svcErr *service.Error
+	if errors.As(err, &svcErr) {
+		gqlErr := gqlerror.WrapPath(graphql.GetPath(ctx), err)
+		gqlErr.Message = svcErr.Message
+		gqlErr.Extensions = map[string]interface{}{"code": svcErr.Code}
+		if svcErr.Field != "" {
+			gqlErr.Extensions["field"] = svcErr.Field
+		}
+		if svcErr.Code == service.CodeInternal {
+			log.Printf("Internal error: %v", err)
+			gqlErr.Message = internalErrorMessage
+		}
+		return gqlErr
+	}
+
+	// Errors raised by gqlgen itself (validation, argument coercion) are already client-safe
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+
+	log.Printf("Unhandled error: %v", err)
+	gqlErr = gqlerror.WrapPath(graphql.GetPath(ctx), err)
+	gqlErr.Message = internalErrorMessage
+	gqlErr.Extensions = map[string]interface{}{"code": service.CodeInternal}
+	return gqlErr
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    service.ErrorCode
+		wantMessage string
+		wantField   string
+	}{
+		{"Not_Found", service.NotFoundf("user with Discord ID %s not found", "abc"), service.CodeNotFound, "user with Discord ID abc not found", ""},
+		{"Invalid_Input_Field", service.InvalidInputf("input.name", "Name is required"), service.CodeInvalidInput, "Name is required", "input.name"},
+		{"Internal_Masked", service.Internal("failed to get user", errors.New("connection refused")), service.CodeInternal, internalErrorMessage, ""},
+		{"Untyped_Masked", errors.New("pq: relation \"users\" does not exist"), service.CodeInternal, internalErrorMessage, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ErrorPresenter(context.Background(), tt.err)
+			if got.Message != tt.wantMessage {
+				t.Errorf("ErrorPresenter() message = %q, want %q", got.Message, tt.wantMessage)
+			}
+			if got.Extensions["code"] != tt.wantCode {
+				t.Errorf("ErrorPresenter() code = %v, want %v", got.Extensions["code"], tt.wantCode)
+			}
+			if field, _ := got.Extensions["field"].(string); field != tt.wantField {
+				t.Errorf("ErrorPresenter() field = %q, want %q", field, tt.wantField)
+			}
+		})
+	}
+}
@@ -170,6 +170,17 @@ func (ec *executionContext) resolveEntity(
 				return nil, fmt.Errorf(`resolving Entity "User": %w`, err)
 			}
 
+			return entity, nil
+		case "findUserByTagNumber":
+			id0, err := ec.unmarshalOInt2ᚖint(ctx, rep["tagNumber"])
+			if err != nil {
+				return nil, fmt.Errorf(`unmarshalling param 0 for findUserByTagNumber(): %w`, err)
+			}
+			entity, err := ec.resolvers.Entity().FindUserByTagNumber(ctx, id0)
+			if err != nil {
+				return nil, fmt.Errorf(`resolving Entity "User": %w`, err)
+			}
+
 			return entity, nil
 		}
 
@@ -222,5 +233,28 @@ func entityResolverNameForUser(ctx context.Context, rep EntityRepresentation) (s
 		}
 		return "findUserByDiscordID", nil
 	}
+	for {
+		var (
+			m   EntityRepresentation
+			val interface{}
+			ok  bool
+		)
+		_ = val
+		// if all of the KeyFields values for this resolver are null,
+		// we shouldn't use use it
+		allNull := true
+		m = rep
+		val, ok = m["tagNumber"]
+		if !ok {
+			break
+		}
+		if allNull {
+			allNull = val == nil
+		}
+		if allNull {
+			break
+		}
+		return "findUserByTagNumber", nil
+	}
 	return "", fmt.Errorf("%w for User", ErrTypeNotFound)
 }
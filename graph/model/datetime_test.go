@@ -0,0 +1,44 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMarshalDateTime(t *testing.T) {
+	at := time.Date(2024, 4, 1, 9, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+
+	var buf bytes.Buffer
+	MarshalDateTime(at).MarshalGQL(&buf)
+	if got, want := buf.String(), `"2024-04-01T13:30:00Z"`; got != want {
+		t.Errorf("MarshalDateTime() = %s, want %s", got, want)
+	}
+}
+
+func TestUnmarshalDateTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   any
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "UTC", input: "2024-04-01T13:30:00Z", want: time.Date(2024, 4, 1, 13, 30, 0, 0, time.UTC)},
+		{name: "Offset", input: "2024-04-01T09:30:00-04:00", want: time.Date(2024, 4, 1, 13, 30, 0, 0, time.UTC)},
+		{name: "Missing_Offset", input: "2024-04-01T13:30:00", wantErr: true},
+		{name: "Date_Only", input: "2024-04-01", wantErr: true},
+		{name: "Not_A_String", input: 1711978200, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalDateTime(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalDateTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("UnmarshalDateTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,26 +2,415 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// A key identifying an internal client such as the bot or scoring service. The key itself is never stored.
+type APIKey struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Prefix    string        `json:"prefix"`
+	Scopes    []APIKeyScope `json:"scopes"`
+	CreatedBy *string       `json:"createdBy,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+	RevokedAt *time.Time    `json:"revokedAt,omitempty"`
+}
+
+// Input type for creating an API key.
+type APIKeyInput struct {
+	Name   string        `json:"name"`
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+// A newly created API key along with its secret.
+type CreatedAPIKey struct {
+	APIKey *APIKey `json:"apiKey"`
+	Key    string  `json:"key"`
+}
+
 // Mutations available in the User Service.
 type Mutation struct {
 }
 
+// Pagination state of a Relay connection. Only forward pagination is supported.
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
 // Queries available in the User Service.
 type Query struct {
 }
 
+// A round participant, listed in finishing order in reassignTags.
+type RoundResultInput struct {
+	DiscordID string `json:"discordID"`
+}
+
+// A participant's tag before and after reassignTags.
+type TagAssignment struct {
+	User              *User `json:"user"`
+	PreviousTagNumber int   `json:"previousTagNumber"`
+	TagNumber         int   `json:"tagNumber"`
+}
+
+// A single change to a user's tag.
+type TagChange struct {
+	DiscordID         string          `json:"discordID"`
+	PreviousTagNumber *int            `json:"previousTagNumber,omitempty"`
+	TagNumber         *int            `json:"tagNumber,omitempty"`
+	Reason            TagChangeReason `json:"reason"`
+	ActorDiscordID    *string         `json:"actorDiscordID,omitempty"`
+	ChangedAt         time.Time       `json:"changedAt"`
+}
+
+// The holder of a tag at some instant.
+type TagStanding struct {
+	TagNumber int       `json:"tagNumber"`
+	DiscordID string    `json:"discordID"`
+	HeldSince time.Time `json:"heldSince"`
+}
+
+// The two users whose tags were exchanged by swapTags.
+type TagSwap struct {
+	UserA *User `json:"userA"`
+	UserB *User `json:"userB"`
+}
+
+// Input type for updating an existing user. Omitted fields are left unchanged.
+type UpdateUserInput struct {
+	Name      graphql.Omittable[*string] `json:"name,omitempty"`
+	TagNumber graphql.Omittable[*int]    `json:"tagNumber,omitempty"`
+}
+
 // Represents a user in the system.
 type User struct {
-	DiscordID string `json:"discordID"`
-	Name      string `json:"name"`
-	TagNumber *int   `json:"tagNumber,omitempty"`
-	Role      string `json:"role"`
+	DiscordID       string         `json:"discordID"`
+	Name            string         `json:"name"`
+	TagNumber       *int           `json:"tagNumber,omitempty"`
+	Role            Role           `json:"role"`
+	Deleted         bool           `json:"deleted"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	TagHistory      []*TagChange   `json:"tagHistory"`
+	Status          ApprovalStatus `json:"status"`
+	RejectionReason *string        `json:"rejectionReason,omitempty"`
+	ReviewedBy      *string        `json:"reviewedBy,omitempty"`
+	ReviewedAt      *time.Time     `json:"reviewedAt,omitempty"`
 }
 
 func (User) IsEntity() {}
 
+// A page of users.
+type UserConnection struct {
+	Edges    []*UserEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+// A user in a Relay connection.
+type UserEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *User  `json:"node"`
+}
+
+// Filters for the users query. Every field that is set must match.
+type UserFilter struct {
+	Role          graphql.Omittable[*Role]           `json:"role,omitempty"`
+	HasTag        graphql.Omittable[*bool]           `json:"hasTag,omitempty"`
+	NamePrefix    graphql.Omittable[*string]         `json:"namePrefix,omitempty"`
+	CreatedAfter  graphql.Omittable[*time.Time]      `json:"createdAfter,omitempty"`
+	CreatedBefore graphql.Omittable[*time.Time]      `json:"createdBefore,omitempty"`
+	Status        graphql.Omittable[*ApprovalStatus] `json:"status,omitempty"`
+}
+
 // Input type for creating a new user.
 type UserInput struct {
-	Name      string `json:"name"`
-	DiscordID string `json:"discordID"`
+	Name      string                   `json:"name"`
+	DiscordID string                   `json:"discordID"`
+	TagNumber graphql.Omittable[*int]  `json:"tagNumber,omitempty"`
+	Role      graphql.Omittable[*Role] `json:"role,omitempty"`
+}
+
+// Ordering of the users query.
+type UserOrder struct {
+	Field     UserOrderField `json:"field"`
+	Direction OrderDirection `json:"direction"`
+}
+
+// A user matched by searchUsers.
+type UserSearchResult struct {
+	User  *User   `json:"user"`
+	Score float64 `json:"score"`
+}
+
+// What an API key may do.
+type APIKeyScope string
+
+const (
+	APIKeyScopeUsersRead  APIKeyScope = "USERS_READ"
+	APIKeyScopeUsersWrite APIKeyScope = "USERS_WRITE"
+	APIKeyScopeTagsRead   APIKeyScope = "TAGS_READ"
+	APIKeyScopeTagsWrite  APIKeyScope = "TAGS_WRITE"
+)
+
+var AllAPIKeyScope = []APIKeyScope{
+	APIKeyScopeUsersRead,
+	APIKeyScopeUsersWrite,
+	APIKeyScopeTagsRead,
+	APIKeyScopeTagsWrite,
+}
+
+func (e APIKeyScope) IsValid() bool {
+	switch e {
+	case APIKeyScopeUsersRead, APIKeyScopeUsersWrite, APIKeyScopeTagsRead, APIKeyScopeTagsWrite:
+		return true
+	}
+	return false
+}
+
+func (e APIKeyScope) String() string {
+	return string(e)
+}
+
+func (e *APIKeyScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = APIKeyScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ApiKeyScope", str)
+	}
+	return nil
+}
+
+func (e APIKeyScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Where a user stands in the sign-up approval queue.
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending  ApprovalStatus = "PENDING"
+	ApprovalStatusApproved ApprovalStatus = "APPROVED"
+	ApprovalStatusRejected ApprovalStatus = "REJECTED"
+)
+
+var AllApprovalStatus = []ApprovalStatus{
+	ApprovalStatusPending,
+	ApprovalStatusApproved,
+	ApprovalStatusRejected,
+}
+
+func (e ApprovalStatus) IsValid() bool {
+	switch e {
+	case ApprovalStatusPending, ApprovalStatusApproved, ApprovalStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ApprovalStatus) String() string {
+	return string(e)
+}
+
+func (e *ApprovalStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ApprovalStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ApprovalStatus", str)
+	}
+	return nil
+}
+
+func (e ApprovalStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+var AllOrderDirection = []OrderDirection{
+	OrderDirectionAsc,
+	OrderDirectionDesc,
+}
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// User roles, from least to most privileged. Each role has every privilege of the roles before it.
+type Role string
+
+const (
+	RoleRattler Role = "Rattler"
+	RoleEditor  Role = "Editor"
+	RoleAdmin   Role = "Admin"
+)
+
+var AllRole = []Role{
+	RoleRattler,
+	RoleEditor,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleRattler, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Why a user's tag changed.
+type TagChangeReason string
+
+const (
+	TagChangeReasonClaim        TagChangeReason = "CLAIM"
+	TagChangeReasonSwap         TagChangeReason = "SWAP"
+	TagChangeReasonReassignment TagChangeReason = "REASSIGNMENT"
+	TagChangeReasonRelease      TagChangeReason = "RELEASE"
+)
+
+var AllTagChangeReason = []TagChangeReason{
+	TagChangeReasonClaim,
+	TagChangeReasonSwap,
+	TagChangeReasonReassignment,
+	TagChangeReasonRelease,
+}
+
+func (e TagChangeReason) IsValid() bool {
+	switch e {
+	case TagChangeReasonClaim, TagChangeReasonSwap, TagChangeReasonReassignment, TagChangeReasonRelease:
+		return true
+	}
+	return false
+}
+
+func (e TagChangeReason) String() string {
+	return string(e)
+}
+
+func (e *TagChangeReason) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TagChangeReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TagChangeReason", str)
+	}
+	return nil
+}
+
+func (e TagChangeReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Fields the users query can be ordered by. Ties are broken by discordID.
+type UserOrderField string
+
+const (
+	UserOrderFieldDiscordID UserOrderField = "DISCORD_ID"
+	UserOrderFieldName      UserOrderField = "NAME"
+	UserOrderFieldCreatedAt UserOrderField = "CREATED_AT"
+)
+
+var AllUserOrderField = []UserOrderField{
+	UserOrderFieldDiscordID,
+	UserOrderFieldName,
+	UserOrderFieldCreatedAt,
+}
+
+func (e UserOrderField) IsValid() bool {
+	switch e {
+	case UserOrderFieldDiscordID, UserOrderFieldName, UserOrderFieldCreatedAt:
+		return true
+	}
+	return false
+}
+
+func (e UserOrderField) String() string {
+	return string(e)
+}
+
+func (e *UserOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UserOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UserOrderField", str)
+	}
+	return nil
+}
+
+func (e UserOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,21 @@
+// graph/model/role.go
+
+package model
+
+// roleLevels ranks the roles from least to most privileged
+var roleLevels = map[Role]int{
+	RoleRattler: 1,
+	RoleEditor:  2,
+	RoleAdmin:   3,
+}
+
+// Level ranks e among the roles, higher being more privileged. Unknown roles have level 0.
+func (e Role) Level() int {
+	return roleLevels[e]
+}
+
+// AtLeast reports whether e grants at least the privileges of min, so Admin is at least Editor.
+// Unknown roles grant nothing.
+func (e Role) AtLeast(min Role) bool {
+	return e.IsValid() && e.Level() >= min.Level()
+}
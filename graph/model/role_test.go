@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestRole_AtLeast(t *testing.T) {
+	tests := []struct {
+		role, min Role
+		want      bool
+	}{
+		{RoleAdmin, RoleEditor, true},
+		{RoleEditor, RoleEditor, true},
+		{RoleRattler, RoleEditor, false},
+		{"", RoleRattler, false},
+		{"Overlord", RoleRattler, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%q.AtLeast(%q) = %v, want %v", tt.role, tt.min, got, tt.want)
+		}
+	}
+}
@@ -4,20 +4,26 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/loaders"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 )
 
 // Resolver struct definition
 type Resolver struct {
-	UserService service.UserService
+	UserService   service.UserService
+	APIKeyService service.APIKeyService
 }
 
 // GetUser  resolver
 func (r *Resolver) GetUser(ctx context.Context, discordID string) (*model.User, error) {
-	user, err := r.UserService.GetUserByDiscordID(ctx, discordID) // Updated method name
+	user, err := loaders.LoadUser(ctx, r.UserService, discordID) // Batched and cached per request
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
 		return nil, err
@@ -34,3 +40,18 @@ func (r *Resolver) CreateUser(ctx context.Context, input model.UserInput) (*mode
 	}
 	return user, nil
 }
+
+// entityUser turns the outcome of an entity lookup into the entity the gateway sees: missing,
+// deleted and unapproved users resolve to null rather than an error
+func entityUser(user *model.User, err error) (*model.User, error) {
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != model.ApprovalStatusApproved {
+		return nil, nil
+	}
+	return user, nil
+}
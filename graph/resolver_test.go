@@ -4,20 +4,40 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 )
 
 // MockUser Service is a mock implementation of the UserService interface
 type MockUserService struct {
-	GetUserByDiscordIDFunc func(ctx context.Context, discordID string) (*model.User, error)
-	CreateUserFunc         func(ctx context.Context, input model.UserInput) (*model.User, error)
+	GetUserByDiscordIDFunc   func(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error)
+	CreateUserFunc           func(ctx context.Context, input model.UserInput) (*model.User, error)
+	UpdateUserFunc           func(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error)
+	DeleteUserFunc           func(ctx context.Context, discordID string) (*model.User, error)
+	RestoreUserFunc          func(ctx context.Context, discordID string) (*model.User, error)
+	GetUserByTagNumberFunc   func(ctx context.Context, tagNumber int) (*model.User, error)
+	ClaimTagFunc             func(ctx context.Context, discordID string, tagNumber int) (*model.User, error)
+	SwapTagsFunc             func(ctx context.Context, discordIDA, discordIDB string) (*model.TagSwap, error)
+	ReassignTagsFunc         func(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error)
+	GetTagHistoryByUserFunc  func(ctx context.Context, discordID string) ([]*model.TagChange, error)
+	GetTagHistoryByTagFunc   func(ctx context.Context, tagNumber int) ([]*model.TagChange, error)
+	GetTagStandingsFunc      func(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error)
+	GetTagLeaderboardFunc    func(ctx context.Context, first int, after *string) (*model.UserConnection, error)
+	ListUsersFunc            func(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error)
+	SearchUsersFunc          func(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error)
+	GetUsersByDiscordIDsFunc func(ctx context.Context, discordIDs []string) ([]*model.User, error)
+	GetUsersByTagNumbersFunc func(ctx context.Context, tagNumbers []int) ([]*model.User, error)
+	UpdateUserRoleFunc       func(ctx context.Context, discordID string, role model.Role) (*model.User, error)
+	ApproveUserFunc          func(ctx context.Context, discordID string) (*model.User, error)
+	RejectUserFunc           func(ctx context.Context, discordID string, reason string) (*model.User, error)
 }
 
 // GetUser ByDiscordID is the mock implementation of the GetUser ByDiscordID method
-func (m *MockUserService) GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
+func (m *MockUserService) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
 	if m.GetUserByDiscordIDFunc != nil {
-		return m.GetUserByDiscordIDFunc(ctx, discordID)
+		return m.GetUserByDiscordIDFunc(ctx, discordID, includeDeleted)
 	}
 	return nil, nil
 }
@@ -30,9 +50,153 @@ func (m *MockUserService) CreateUser(ctx context.Context, input model.UserInput)
 	return nil, nil
 }
 
+// UpdateUser is the mock implementation of the UpdateUser method
+func (m *MockUserService) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	if m.UpdateUserFunc != nil {
+		return m.UpdateUserFunc(ctx, discordID, input)
+	}
+	return nil, nil
+}
+
+// DeleteUser is the mock implementation of the DeleteUser method
+func (m *MockUserService) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	if m.DeleteUserFunc != nil {
+		return m.DeleteUserFunc(ctx, discordID)
+	}
+	return nil, nil
+}
+
+// RestoreUser is the mock implementation of the RestoreUser method
+func (m *MockUserService) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	if m.RestoreUserFunc != nil {
+		return m.RestoreUserFunc(ctx, discordID)
+	}
+	return nil, nil
+}
+
+// GetUserByTagNumber is the mock implementation of the GetUserByTagNumber method
+func (m *MockUserService) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	if m.GetUserByTagNumberFunc != nil {
+		return m.GetUserByTagNumberFunc(ctx, tagNumber)
+	}
+	return nil, nil
+}
+
+// ClaimTag is the mock implementation of the ClaimTag method
+func (m *MockUserService) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	if m.ClaimTagFunc != nil {
+		return m.ClaimTagFunc(ctx, discordID, tagNumber)
+	}
+	return nil, nil
+}
+
+// SwapTags is the mock implementation of the SwapTags method
+func (m *MockUserService) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.TagSwap, error) {
+	if m.SwapTagsFunc != nil {
+		return m.SwapTagsFunc(ctx, discordIDA, discordIDB)
+	}
+	return nil, nil
+}
+
+// ReassignTags is the mock implementation of the ReassignTags method
+func (m *MockUserService) ReassignTags(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error) {
+	if m.ReassignTagsFunc != nil {
+		return m.ReassignTagsFunc(ctx, results)
+	}
+	return nil, nil
+}
+
+// GetTagHistoryByUser is the mock implementation of the GetTagHistoryByUser method
+func (m *MockUserService) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	if m.GetTagHistoryByUserFunc != nil {
+		return m.GetTagHistoryByUserFunc(ctx, discordID)
+	}
+	return nil, nil
+}
+
+// GetTagHistoryByTag is the mock implementation of the GetTagHistoryByTag method
+func (m *MockUserService) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	if m.GetTagHistoryByTagFunc != nil {
+		return m.GetTagHistoryByTagFunc(ctx, tagNumber)
+	}
+	return nil, nil
+}
+
+// GetTagStandings is the mock implementation of the GetTagStandings method
+func (m *MockUserService) GetTagStandings(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error) {
+	if m.GetTagStandingsFunc != nil {
+		return m.GetTagStandingsFunc(ctx, asOf)
+	}
+	return nil, nil
+}
+
+// GetTagLeaderboard is the mock implementation of the GetTagLeaderboard method
+func (m *MockUserService) GetTagLeaderboard(ctx context.Context, first int, after *string) (*model.UserConnection, error) {
+	if m.GetTagLeaderboardFunc != nil {
+		return m.GetTagLeaderboardFunc(ctx, first, after)
+	}
+	return nil, nil
+}
+
+// ListUsers is the mock implementation of the ListUsers method
+func (m *MockUserService) ListUsers(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error) {
+	if m.ListUsersFunc != nil {
+		return m.ListUsersFunc(ctx, filter, orderBy, first, after)
+	}
+	return nil, nil
+}
+
+// SearchUsers is the mock implementation of the SearchUsers method
+func (m *MockUserService) SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error) {
+	if m.SearchUsersFunc != nil {
+		return m.SearchUsersFunc(ctx, query, limit)
+	}
+	return nil, nil
+}
+
+// GetUsersByDiscordIDs is the mock implementation of the GetUsersByDiscordIDs method
+func (m *MockUserService) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	if m.GetUsersByDiscordIDsFunc != nil {
+		return m.GetUsersByDiscordIDsFunc(ctx, discordIDs)
+	}
+	return nil, nil
+}
+
+// GetUsersByTagNumbers is the mock implementation of the GetUsersByTagNumbers method
+func (m *MockUserService) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	if m.GetUsersByTagNumbersFunc != nil {
+		return m.GetUsersByTagNumbersFunc(ctx, tagNumbers)
+	}
+	return nil, nil
+}
+
+// UpdateUserRole is the mock implementation of the UpdateUserRole method
+func (m *MockUserService) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	if m.UpdateUserRoleFunc != nil {
+		return m.UpdateUserRoleFunc(ctx, discordID, role)
+	}
+	return nil, nil
+}
+
+// ApproveUser is the mock implementation of the ApproveUser method
+func (m *MockUserService) ApproveUser(ctx context.Context, discordID string) (*model.User, error) {
+	if m.ApproveUserFunc != nil {
+		return m.ApproveUserFunc(ctx, discordID)
+	}
+	return nil, nil
+}
+
+// RejectUser is the mock implementation of the RejectUser method
+func (m *MockUserService) RejectUser(ctx context.Context, discordID string, reason string) (*model.User, error) {
+	if m.RejectUserFunc != nil {
+		return m.RejectUserFunc(ctx, discordID, reason)
+	}
+	return nil, nil
+}
+
 func TestResolver_GetUser(t *testing.T) {
 	mockUserService := &MockUserService{
-		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string) (*model.User, error) {
+		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
 			if discordID == "existingID" {
 				return &model.User{DiscordID: discordID, Name: "Existing User"}, nil
 			}
@@ -54,7 +218,7 @@ func TestResolver_GetUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := resolver.UserService.GetUserByDiscordID(context.Background(), tt.discordID)
+			got, err := resolver.UserService.GetUserByDiscordID(context.Background(), tt.discordID, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Resolver.GetUser ByDiscordID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -68,7 +232,7 @@ func TestResolver_GetUser(t *testing.T) {
 
 func TestResolver_CreateUser(t *testing.T) {
 	mockUserService := &MockUserService{
-		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string) (*model.User, error) {
+		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
 			if discordID == "existingID" {
 				return &model.User{DiscordID: discordID, Name: "Existing User"}, nil
 			}
@@ -107,3 +271,17 @@ func TestResolver_CreateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryResolver_GetUser_NotFound(t *testing.T) {
+	mockUserService := &MockUserService{
+		GetUserByDiscordIDFunc: func(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+			return nil, service.NotFoundf("user with Discord ID %s not found", discordID)
+		},
+	}
+
+	query := &queryResolver{&Resolver{UserService: mockUserService}}
+	got, err := query.GetUser(context.Background(), "missingID", false)
+	if err != nil || got != nil {
+		t.Errorf("queryResolver.GetUser() = %v, %v, want nil, nil", got, err)
+	}
+}
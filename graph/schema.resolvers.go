@@ -7,9 +7,13 @@ package graph
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/loaders"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 )
 
 // CreateUser  is the resolver for the createUser  field.
@@ -17,26 +21,245 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.UserInput
 	// Call the UserService's CreateUser  method to create a new user
 	user, err := r.UserService.CreateUser(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, err
 	}
 	return user, nil
 }
 
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	// Call the UserService's UpdateUser method to apply only the provided fields
+	user, err := r.UserService.UpdateUser(ctx, discordID, input)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	// Call the UserService's DeleteUser method to soft-delete the user
+	user, err := r.UserService.DeleteUser(ctx, discordID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RestoreUser is the resolver for the restoreUser field.
+func (r *mutationResolver) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	// Call the UserService's RestoreUser method to undo a soft-delete
+	user, err := r.UserService.RestoreUser(ctx, discordID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ClaimTag is the resolver for the claimTag field.
+func (r *mutationResolver) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	// Call the UserService's ClaimTag method to assign the tag atomically
+	user, err := r.UserService.ClaimTag(ctx, discordID, tagNumber)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SwapTags is the resolver for the swapTags field.
+func (r *mutationResolver) SwapTags(ctx context.Context, discordIDA string, discordIDB string) (*model.TagSwap, error) {
+	// Call the UserService's SwapTags method to exchange both tags atomically
+	swap, err := r.UserService.SwapTags(ctx, discordIDA, discordIDB)
+	if err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+// ReassignTags is the resolver for the reassignTags field.
+func (r *mutationResolver) ReassignTags(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error) {
+	// Call the UserService's ReassignTags method to redistribute tags by finishing order
+	assignments, err := r.UserService.ReassignTags(ctx, results)
+	if err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// UpdateUserRole is the resolver for the updateUserRole field.
+func (r *mutationResolver) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	// Call the UserService's UpdateUserRole method, which enforces the role hierarchy
+	user, err := r.UserService.UpdateUserRole(ctx, discordID, role)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ApproveUser is the resolver for the approveUser field.
+func (r *mutationResolver) ApproveUser(ctx context.Context, discordID string) (*model.User, error) {
+	// Call the UserService's ApproveUser method to admit a pending sign-up
+	user, err := r.UserService.ApproveUser(ctx, discordID)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RejectUser is the resolver for the rejectUser field.
+func (r *mutationResolver) RejectUser(ctx context.Context, discordID string, reason string) (*model.User, error) {
+	// Call the UserService's RejectUser method to turn down a pending sign-up
+	user, err := r.UserService.RejectUser(ctx, discordID, reason)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateAPIKey is the resolver for the createApiKey field.
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, input model.APIKeyInput) (*model.CreatedAPIKey, error) {
+	// Call the APIKeyService's CreateAPIKey method to issue a key shown only in this response
+	created, err := r.APIKeyService.CreateAPIKey(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// RevokeAPIKey is the resolver for the revokeApiKey field.
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	// Call the APIKeyService's RevokeAPIKey method to disable the key
+	key, err := r.APIKeyService.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
 // GetUser  is the resolver for the getUser  field.
-func (r *queryResolver) GetUser(ctx context.Context, discordID string) (*model.User, error) {
-	// Call the UserService's GetUser ByDiscordID method to retrieve the user
-	user, err := r.UserService.GetUserByDiscordID(ctx, discordID) // Updated method name
+func (r *queryResolver) GetUser(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	// Active users go through the request's loader; includeDeleted lookups call the UserService directly
+	var user *model.User
+	var err error
+	if includeDeleted {
+		user, err = r.UserService.GetUserByDiscordID(ctx, discordID, true)
+	} else {
+		user, err = loaders.LoadUser(ctx, r.UserService, discordID)
+	}
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil // getUser is nullable, so a missing user is not an error
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user with Discord ID %s: %v", discordID, err)
+		return nil, err
 	}
 	return user, nil
 }
 
+// GetUserByTagNumber is the resolver for the getUserByTagNumber field.
+func (r *queryResolver) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	// Call the UserService's GetUserByTagNumber method to find the tag holder
+	user, err := r.UserService.GetUserByTagNumber(ctx, tagNumber)
+	if errors.Is(err, service.ErrNotFound) {
+		return nil, nil // Unclaimed tags resolve to null
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// TagHistory is the resolver for the tagHistory field.
+func (r *queryResolver) TagHistory(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	// Call the UserService's GetTagHistoryByTag method to list every holder of the tag
+	history, err := r.UserService.GetTagHistoryByTag(ctx, tagNumber)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// TagStandings is the resolver for the tagStandings field.
+func (r *queryResolver) TagStandings(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error) {
+	// Call the UserService's GetTagStandings method to reconstruct the tag board at asOf
+	standings, err := r.UserService.GetTagStandings(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return standings, nil
+}
+
+// TagLeaderboard is the resolver for the tagLeaderboard field.
+func (r *queryResolver) TagLeaderboard(ctx context.Context, first int, after *string) (*model.UserConnection, error) {
+	// Call the UserService's GetTagLeaderboard method to page through the tag holders
+	connection, err := r.UserService.GetTagLeaderboard(ctx, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// SearchUsers is the resolver for the searchUsers field.
+func (r *queryResolver) SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error) {
+	// Call the UserService's SearchUsers method to rank users by name similarity
+	results, err := r.UserService.SearchUsers(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error) {
+	// Call the UserService's ListUsers method to page through the filtered roster
+	connection, err := r.UserService.ListUsers(ctx, filter, orderBy, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// APIKeys is the resolver for the apiKeys field.
+func (r *queryResolver) APIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	// Call the APIKeyService's ListAPIKeys method
+	keys, err := r.APIKeyService.ListAPIKeys(ctx, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// PendingUsers is the resolver for the pendingUsers field.
+func (r *queryResolver) PendingUsers(ctx context.Context, first int, after *string) (*model.UserConnection, error) {
+	// List pending users through the users query's filter, oldest sign-up first
+	pending := model.ApprovalStatusPending
+	filter := &model.UserFilter{Status: graphql.OmittableOf(&pending)}
+	orderBy := &model.UserOrder{Field: model.UserOrderFieldCreatedAt, Direction: model.OrderDirectionAsc}
+	connection, err := r.UserService.ListUsers(ctx, filter, orderBy, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// TagHistory is the resolver for the tagHistory field.
+func (r *userResolver) TagHistory(ctx context.Context, obj *model.User) ([]*model.TagChange, error) {
+	// Call the UserService's GetTagHistoryByUser method to list the user's tag changes
+	history, err := r.UserService.GetTagHistoryByUser(ctx, obj.DiscordID)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
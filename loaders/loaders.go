@@ -0,0 +1,229 @@
+// loaders/loaders.go
+
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// batchWait is how long a loader collects keys before fetching them in one batch
+const batchWait = time.Millisecond
+
+// maxBatch dispatches a batch early once it holds this many keys
+const maxBatch = 100
+
+type contextKey struct{}
+
+// tagContextKey stores the request's loader of users by tag number
+type tagContextKey struct{}
+
+// Middleware gives every request its own UserLoader by Discord ID and by tag number, so lookups
+// are batched and cached for the lifetime of that request only
+func Middleware(users service.UserService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			loader := NewUserLoader(ctx, users.GetUsersByDiscordIDs)
+			ctx = context.WithValue(ctx, contextKey{}, loader)
+			ctx = context.WithValue(ctx, tagContextKey{}, newTagLoader(ctx, users))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// newTagLoader creates a UserLoader keyed by decimal tag numbers, fetching tag holders in one query
+func newTagLoader(ctx context.Context, users service.UserService) *UserLoader {
+	loader := NewUserLoader(ctx, func(ctx context.Context, keys []string) ([]*model.User, error) {
+		tagNumbers := make([]int, len(keys))
+		for i, key := range keys {
+			tagNumbers[i], _ = strconv.Atoi(key) // Keys are only ever formatted by LoadUserByTagNumber
+		}
+		return users.GetUsersByTagNumbers(ctx, tagNumbers)
+	})
+	loader.notFoundFormat = "no user holds tag %s"
+	return loader
+}
+
+// For returns the request's UserLoader, or nil outside a request served through Middleware
+func For(ctx context.Context) *UserLoader {
+	loader, _ := ctx.Value(contextKey{}).(*UserLoader)
+	return loader
+}
+
+// LoadUser returns the active user with discordID through the request's loader, falling back to
+// users.GetUserByDiscordID when the context has no loader. A missing user matches service.ErrNotFound.
+func LoadUser(ctx context.Context, users service.UserService, discordID string) (*model.User, error) {
+	loader := For(ctx)
+	if loader == nil || discordID == "" {
+		return users.GetUserByDiscordID(ctx, discordID, false)
+	}
+	return loader.Load(ctx, discordID)
+}
+
+// LoadUserByTagNumber returns the active user holding tagNumber through the request's tag loader,
+// falling back to users.GetUserByTagNumber when the context has no loader. An unheld tag matches
+// service.ErrNotFound.
+func LoadUserByTagNumber(ctx context.Context, users service.UserService, tagNumber int) (*model.User, error) {
+	loader, _ := ctx.Value(tagContextKey{}).(*UserLoader)
+	if loader == nil {
+		return users.GetUserByTagNumber(ctx, tagNumber)
+	}
+	return loader.Load(ctx, strconv.Itoa(tagNumber))
+}
+
+// LoadUsers returns the active users with discordIDs in order, with nil for each missing user,
+// through the request's loader when the context has one
+func LoadUsers(ctx context.Context, users service.UserService, discordIDs []string) ([]*model.User, error) {
+	loader := For(ctx)
+	if loader == nil {
+		return users.GetUsersByDiscordIDs(ctx, discordIDs)
+	}
+	return loader.LoadMany(ctx, discordIDs)
+}
+
+// FetchFunc loads users in one call, returning a slice lined up with discordIDs that holds nil for
+// each missing user, like UserService.GetUsersByDiscordIDs
+type FetchFunc func(ctx context.Context, discordIDs []string) ([]*model.User, error)
+
+// UserLoader batches the user lookups made within batchWait of each other into one fetch, and
+// caches each result so a user is fetched at most once. It is safe for concurrent use.
+type UserLoader struct {
+	ctx            context.Context // Context of the request the loader belongs to, used for every fetch
+	fetch          FetchFunc
+	notFoundFormat string // Message of the error for a key with no user, given the key
+
+	mu    sync.Mutex
+	cache map[string]*result
+	batch *batch // Keys waiting to be fetched, or nil
+}
+
+// result is the eventual outcome of loading one key; done is closed once it is set
+type result struct {
+	done chan struct{}
+	user *model.User
+	err  error
+}
+
+// batch is a set of distinct keys fetched together
+type batch struct {
+	keys       []string
+	results    []*result
+	dispatched bool // Guarded by UserLoader.mu
+}
+
+// NewUserLoader creates a UserLoader that fetches with ctx
+func NewUserLoader(ctx context.Context, fetch FetchFunc) *UserLoader {
+	return &UserLoader{ctx: ctx, fetch: fetch, notFoundFormat: "user with Discord ID %s not found", cache: make(map[string]*result)}
+}
+
+// Load returns the user with discordID, or an error matching service.ErrNotFound if there is none
+func (l *UserLoader) Load(ctx context.Context, discordID string) (*model.User, error) {
+	l.mu.Lock()
+	r := l.enqueue(discordID)
+	l.mu.Unlock()
+
+	return r.wait(ctx)
+}
+
+// LoadMany returns the users with discordIDs in order, with nil for each missing user. Every
+// key not already cached goes into the same batch.
+func (l *UserLoader) LoadMany(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	l.mu.Lock()
+	results := make([]*result, len(discordIDs))
+	for i, id := range discordIDs {
+		results[i] = l.enqueue(id)
+	}
+	l.mu.Unlock()
+
+	users := make([]*model.User, len(discordIDs))
+	for i, r := range results {
+		user, err := r.wait(ctx)
+		if err != nil && !errors.Is(err, service.ErrNotFound) {
+			return nil, err
+		}
+		users[i] = user
+	}
+	return users, nil
+}
+
+// enqueue returns the cached result for discordID, adding the key to the pending batch if it has
+// not been requested yet. The caller must hold l.mu.
+func (l *UserLoader) enqueue(discordID string) *result {
+	if r, ok := l.cache[discordID]; ok {
+		return r
+	}
+
+	r := &result{done: make(chan struct{})}
+	l.cache[discordID] = r
+	if l.batch == nil {
+		b := &batch{}
+		l.batch = b
+		time.AfterFunc(batchWait, func() { l.dispatch(b) })
+	}
+	l.batch.keys = append(l.batch.keys, discordID)
+	l.batch.results = append(l.batch.results, r)
+	if len(l.batch.keys) >= maxBatch {
+		go l.dispatch(l.batch)
+		l.batch = nil
+	}
+	return r
+}
+
+// dispatch fetches a batch and settles its results. Failed keys are dropped from the cache so a
+// later load retries them.
+func (l *UserLoader) dispatch(b *batch) {
+	l.mu.Lock()
+	if b.dispatched {
+		// Dispatched early when it filled up; this is the timer firing afterwards
+		l.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	users, err := l.fetch(l.ctx, b.keys)
+	if err == nil && len(users) != len(b.keys) {
+		err = service.Internal("failed to load users", fmt.Errorf("fetched %d users for %d keys", len(users), len(b.keys)))
+	}
+
+	if err != nil {
+		l.mu.Lock()
+		for _, key := range b.keys {
+			delete(l.cache, key)
+		}
+		l.mu.Unlock()
+	}
+	for i, r := range b.results {
+		switch {
+		case err != nil:
+			r.err = err
+		case users[i] == nil:
+			r.err = service.NotFoundf(l.notFoundFormat, b.keys[i])
+		default:
+			r.user = users[i]
+		}
+		close(r.done)
+	}
+}
+
+// wait blocks until the result is settled or ctx is done
+func (r *result) wait(ctx context.Context) (*model.User, error) {
+	select {
+	case <-r.done:
+		return r.user, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,162 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// recordingFetch finds a user for every key except "missing", and records the keys of every fetch
+type recordingFetch struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (f *recordingFetch) fetch(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, append([]string(nil), discordIDs...))
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, len(discordIDs))
+	for i, id := range discordIDs {
+		if id != "missing" {
+			users[i] = &model.User{DiscordID: id, Name: "User " + id}
+		}
+	}
+	return users, nil
+}
+
+func (f *recordingFetch) calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestUserLoader_BatchesAndDeduplicates(t *testing.T) {
+	f := &recordingFetch{}
+	loader := NewUserLoader(context.Background(), f.fetch)
+
+	ids := []string{"a", "b", "a", "c", "b", "missing"}
+	var wg sync.WaitGroup
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := loader.Load(context.Background(), id)
+			if err == nil && user.DiscordID != id {
+				t.Errorf("Load(%s) = %s", id, user.DiscordID)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	calls := f.calls()
+	if len(calls) != 1 {
+		t.Fatalf("fetched %d batches, want 1: %v", len(calls), calls)
+	}
+	keys := calls[0]
+	sort.Strings(keys)
+	if want := []string{"a", "b", "c", "missing"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("fetched keys %v, want %v", keys, want)
+	}
+	for i, id := range ids {
+		if wantNotFound := id == "missing"; errors.Is(errs[i], service.ErrNotFound) != wantNotFound {
+			t.Errorf("Load(%s) error = %v", id, errs[i])
+		}
+	}
+
+	// Every key is cached for the rest of the request, including missing users
+	if _, err := loader.Load(context.Background(), "a"); err != nil {
+		t.Errorf("Load(a) cached error = %v", err)
+	}
+	if _, err := loader.Load(context.Background(), "missing"); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("Load(missing) cached error = %v, want ErrNotFound", err)
+	}
+	if calls := f.calls(); len(calls) != 1 {
+		t.Errorf("cached loads fetched again: %v", calls)
+	}
+}
+
+func TestUserLoader_LoadMany(t *testing.T) {
+	f := &recordingFetch{}
+	loader := NewUserLoader(context.Background(), f.fetch)
+
+	users, err := loader.LoadMany(context.Background(), []string{"b", "missing", "a", "b"})
+	if err != nil {
+		t.Fatalf("LoadMany() error = %v", err)
+	}
+	if users[0].DiscordID != "b" || users[1] != nil || users[2].DiscordID != "a" || users[3].DiscordID != "b" {
+		t.Errorf("LoadMany() = %v, want [b nil a b]", users)
+	}
+	if calls := f.calls(); len(calls) != 1 || len(calls[0]) != 3 {
+		t.Errorf("LoadMany() fetched %v, want one batch of 3 keys", calls)
+	}
+}
+
+func TestUserLoader_MaxBatch(t *testing.T) {
+	f := &recordingFetch{}
+	loader := NewUserLoader(context.Background(), f.fetch)
+
+	ids := make([]string, maxBatch+1)
+	for i := range ids {
+		ids[i] = string(rune('A' + i))
+	}
+	if _, err := loader.LoadMany(context.Background(), ids); err != nil {
+		t.Fatalf("LoadMany() error = %v", err)
+	}
+	calls := f.calls()
+	if len(calls) != 2 || len(calls[0])+len(calls[1]) != len(ids) {
+		t.Errorf("fetched %d batches, want %d keys split in 2", len(calls), len(ids))
+	}
+}
+
+func TestUserLoader_ErrorsAreNotCached(t *testing.T) {
+	f := &recordingFetch{err: errors.New("connection refused")}
+	loader := NewUserLoader(context.Background(), f.fetch)
+
+	if _, err := loader.Load(context.Background(), "a"); err == nil {
+		t.Fatal("Load() expected fetch error")
+	}
+
+	f.mu.Lock()
+	f.err = nil
+	f.mu.Unlock()
+	if user, err := loader.Load(context.Background(), "a"); err != nil || user.DiscordID != "a" {
+		t.Errorf("Load() after failure = %v, %v, want a", user, err)
+	}
+	if calls := f.calls(); len(calls) != 2 {
+		t.Errorf("fetched %d batches, want a retry after the failure", len(calls))
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var loaders []*UserLoader
+	handler := Middleware(service.NewUserService(service.NewMemoryClient()))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loaders = append(loaders, For(r.Context()))
+	}))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/graphql", nil))
+	}
+	if loaders[0] == nil || loaders[1] == nil || loaders[0] == loaders[1] {
+		t.Errorf("Middleware() loaders = %v, want a distinct loader per request", loaders)
+	}
+	if For(context.Background()) != nil {
+		t.Error("For() without Middleware should return nil")
+	}
+}
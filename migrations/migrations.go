@@ -0,0 +1,249 @@
+// migrations/migrations.go
+
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// fileName matches migration files such as 0001_create_users.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockID is the advisory lock key that serializes migrations across replicas
+const lockID = 7_421_001
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // Hex SHA-256 of Up, recorded when the migration is applied
+}
+
+// DB is the subset of *pgxpool.Pool the Migrator needs
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migrator applies and rolls back the embedded migrations
+type Migrator struct {
+	DB         DB
+	Migrations []Migration
+}
+
+// New creates a Migrator for the migrations embedded in the binary
+func New(db DB) (*Migrator, error) {
+	migrations, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// Load reads the migrations under sql/ in fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, "sql/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns how many ran
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		ran, err := m.apply(ctx, migration)
+		if err != nil {
+			return count, err
+		}
+		if ran {
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Down rolls back the most recently applied migrations, up to steps of them, and returns how many ran
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.revert(ctx, migration); err != nil {
+			return count, err
+		}
+		log.Printf("Reverted migration %d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// applied creates the migrations table if needed and returns the recorded checksum of each applied
+// version, failing if an applied migration was edited or is unknown to this binary
+func (m *Migrator) applied(ctx context.Context) (map[int]string, error) {
+	_, err := m.DB.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := m.DB.Query(ctx, "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = checksum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	known := make(map[int]Migration, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+	for version, checksum := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d is unknown to this binary", version)
+		}
+		if migration.Checksum != checksum {
+			return nil, fmt.Errorf("migration %d_%s was modified after it was applied", version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs a migration under the advisory lock, skipping it if another replica applied it first
+func (m *Migrator) apply(ctx context.Context, migration Migration) (bool, error) {
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		return false, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", migration.Version).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %w", migration.Version, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, migration.Up); err != nil {
+		return false, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, migration.Checksum); err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+	return true, tx.Commit(ctx)
+}
+
+// revert runs a migration's down script and removes its record
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin rollback of migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	if _, err := tx.Exec(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("failed to remove migration record %d: %w", migration.Version, err)
+	}
+	return tx.Commit(ctx)
+}
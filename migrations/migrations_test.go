@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Load() returned no migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s has version %d, want %d", m.Version, m.Name, m.Version, i+1)
+		}
+		if m.Checksum == "" {
+			t.Errorf("migration %d_%s has no checksum", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoad_MissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+	}
+	if _, err := Load(fsys); err == nil {
+		t.Error("Load() expected error for migration without down file")
+	}
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create pgxmock pool: %v", err)
+	}
+	migrations, err := Load(fstest.MapFS{
+		"sql/0001_create_roles.up.sql":   {Data: []byte("CREATE TABLE roles ();")},
+		"sql/0001_create_roles.down.sql": {Data: []byte("DROP TABLE roles;")},
+		"sql/0002_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"sql/0002_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return &Migrator{DB: mock, Migrations: migrations}, mock
+}
+
+func TestMigrator_Up(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectQuery("SELECT version, checksum FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "checksum"}).AddRow(1, migrator.Migrations[0].Checksum))
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(lockID).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(2).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`CREATE TABLE users \(\);`).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(2, "create_users", migrator.Migrations[1].Checksum).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	count, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Up() applied %d migrations, want 1", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMigrator_Up_ChecksumMismatch(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectQuery("SELECT version, checksum FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "checksum"}).AddRow(1, "edited"))
+
+	_, err := migrator.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("Up() error = %v, want checksum mismatch", err)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectQuery("SELECT version, checksum FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"version", "checksum"}).
+			AddRow(1, migrator.Migrations[0].Checksum).
+			AddRow(2, migrator.Migrations[1].Checksum))
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(lockID).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec("DROP TABLE users;").WillReturnResult(pgxmock.NewResult("DROP", 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(2).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	count, err := migrator.Down(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Down() reverted %d migrations, want 1", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    rank INT NOT NULL UNIQUE
+);
+
+INSERT INTO roles (name, rank) VALUES
+    ('Rattler', 1),
+    ('Editor', 2),
+    ('Admin', 3);
@@ -0,0 +1,43 @@
+-- Deployed databases already hold a users table created by hand before migrations existed, with
+-- discord_id and name and possibly some later columns, so adopt it rather than failing
+CREATE TABLE IF NOT EXISTS users (
+    discord_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tag_number INT,
+    ADD COLUMN IF NOT EXISTS role TEXT,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Columns an adopted table already had may allow values the service cannot read, so fill the gaps
+-- before tightening them. Unknown roles are left to fail the foreign key below rather than be guessed.
+UPDATE users SET name = discord_id WHERE name IS NULL;
+UPDATE users SET role = 'Rattler' WHERE role IS NULL;
+UPDATE users SET created_at = now() WHERE created_at IS NULL;
+UPDATE users SET tag_number = NULL WHERE tag_number <= 0;
+
+ALTER TABLE users
+    ALTER COLUMN name SET NOT NULL,
+    ALTER COLUMN role SET DEFAULT 'Rattler',
+    ALTER COLUMN role SET NOT NULL,
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS users_tag_number_idx ON users (tag_number) WHERE tag_number IS NOT NULL;
+
+-- Give an adopted table the constraints a new one is created with: later tables reference
+-- users (discord_id), and roles and tags must stay valid
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND contype = 'p') THEN
+        ALTER TABLE users ADD PRIMARY KEY (discord_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND contype = 'f' AND confrelid = 'roles'::regclass) THEN
+        ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND conname = 'users_tag_number_check') THEN
+        ALTER TABLE users ADD CONSTRAINT users_tag_number_check CHECK (tag_number > 0);
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+DROP INDEX users_tag_number_key;
+
+CREATE INDEX users_tag_number_idx ON users (tag_number) WHERE tag_number IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX users_tag_number_idx;
+
+CREATE UNIQUE INDEX users_tag_number_key ON users (tag_number);
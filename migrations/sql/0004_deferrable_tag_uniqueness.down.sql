@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT users_tag_number_key;
+
+CREATE UNIQUE INDEX users_tag_number_key ON users (tag_number);
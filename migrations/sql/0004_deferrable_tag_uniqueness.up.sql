@@ -0,0 +1,5 @@
+-- A deferrable constraint is checked at the end of each statement rather than per row,
+-- so tags can be swapped or reshuffled in a single UPDATE.
+DROP INDEX users_tag_number_key;
+
+ALTER TABLE users ADD CONSTRAINT users_tag_number_key UNIQUE (tag_number) DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,17 @@
+CREATE TABLE tag_history (
+    id BIGSERIAL PRIMARY KEY,
+    discord_id TEXT NOT NULL REFERENCES users (discord_id),
+    previous_tag_number INT,
+    tag_number INT,
+    reason TEXT NOT NULL,
+    actor_discord_id TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX tag_history_discord_id_idx ON tag_history (discord_id, changed_at);
+CREATE INDEX tag_history_tag_number_idx ON tag_history (tag_number, changed_at);
+CREATE INDEX tag_history_previous_tag_number_idx ON tag_history (previous_tag_number, changed_at);
+
+-- Seed the history with the tags already held so ownership can be traced from here on
+INSERT INTO tag_history (discord_id, tag_number, reason)
+SELECT discord_id, tag_number, 'CLAIM' FROM users WHERE tag_number IS NOT NULL;
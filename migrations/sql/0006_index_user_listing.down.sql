@@ -0,0 +1,2 @@
+DROP INDEX users_created_at_idx;
+DROP INDEX users_name_idx;
@@ -0,0 +1,3 @@
+-- Keyset pagination of the users query seeks on (sort column, discord_id)
+CREATE INDEX users_name_idx ON users (name, discord_id) WHERE deleted_at IS NULL;
+CREATE INDEX users_created_at_idx ON users (created_at, discord_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+DROP INDEX users_pending_idx;
+
+ALTER TABLE users
+    DROP COLUMN status,
+    DROP COLUMN rejection_reason,
+    DROP COLUMN reviewed_by,
+    DROP COLUMN reviewed_at;
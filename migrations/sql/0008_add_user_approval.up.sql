@@ -0,0 +1,8 @@
+-- Existing users were created before approval existed, so they count as approved
+ALTER TABLE users
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'APPROVED' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    ADD COLUMN rejection_reason TEXT,
+    ADD COLUMN reviewed_by TEXT,
+    ADD COLUMN reviewed_at TIMESTAMPTZ;
+
+CREATE INDEX users_pending_idx ON users (created_at, discord_id) WHERE status = 'PENDING' AND deleted_at IS NULL;
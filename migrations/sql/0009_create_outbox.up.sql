@@ -0,0 +1,13 @@
+-- Domain events written in the same transaction as the change they describe, relayed in id order
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    discord_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,2 @@
+-- Released tags may have been claimed since, so they are not handed back
+SELECT 1;
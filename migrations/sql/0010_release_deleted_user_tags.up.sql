@@ -0,0 +1,5 @@
+-- Soft-deleting a user now releases their tag; free the tags deleted users still hold
+INSERT INTO tag_history (discord_id, previous_tag_number, tag_number, reason)
+SELECT discord_id, tag_number, NULL, 'RELEASE' FROM users WHERE deleted_at IS NOT NULL AND tag_number IS NOT NULL;
+
+UPDATE users SET tag_number = NULL WHERE deleted_at IS NOT NULL AND tag_number IS NOT NULL;
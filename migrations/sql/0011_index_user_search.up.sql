@@ -0,0 +1,3 @@
+-- searchUsers prefilters names by trigram word similarity before scoring them
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX users_name_trgm_idx ON users USING gin (name gin_trgm_ops) WHERE deleted_at IS NULL AND status = 'APPROVED';
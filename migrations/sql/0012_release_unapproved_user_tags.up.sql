@@ -0,0 +1,5 @@
+-- Only approved users may hold tags; free the tags pending and rejected users still hold
+INSERT INTO tag_history (discord_id, previous_tag_number, tag_number, reason)
+SELECT discord_id, tag_number, NULL, 'RELEASE' FROM users WHERE status <> 'APPROVED' AND tag_number IS NOT NULL;
+
+UPDATE users SET tag_number = NULL WHERE status <> 'APPROVED' AND tag_number IS NOT NULL;
@@ -1,10 +1,13 @@
 package mocks
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"time"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
-	"github.com/jackc/pgx/v5"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 	"github.com/pashagolub/pgxmock/v4"
 )
 
@@ -29,12 +32,245 @@ func (m *PGClientMock) CreateUser(ctx context.Context, user *model.User) error {
 }
 
 // GetUserByDiscordID is a mock implementation of the GetUserByDiscordID method
-func (m *PGClientMock) GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
+func (m *PGClientMock) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
 	// Here, we return specific values to simulate the database responses.
 	if discordID == "validID" {
-		return &model.User{DiscordID: discordID, Name: "Test User"}, nil
+		return &model.User{DiscordID: discordID, Name: "Test User", Role: service.DefaultRole, Status: model.ApprovalStatusApproved}, nil
 	}
-	return nil, pgx.ErrNoRows
+	if discordID == "pendingID" {
+		return &model.User{DiscordID: discordID, Name: "Pending User", Role: service.DefaultRole, Status: model.ApprovalStatusPending}, nil
+	}
+	if discordID == "deletedID" && includeDeleted {
+		return &model.User{DiscordID: discordID, Name: "Deleted User", Role: service.DefaultRole, Status: model.ApprovalStatusApproved, Deleted: true}, nil
+	}
+	return nil, service.NotFoundf("user with Discord ID %s not found", discordID)
+}
+
+// GetUsersByDiscordIDs is a mock implementation of the GetUsersByDiscordIDs method; only validID and pendingID are found
+func (m *PGClientMock) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	users := make([]*model.User, len(discordIDs))
+	for i, id := range discordIDs {
+		users[i], _ = m.GetUserByDiscordID(ctx, id, false)
+	}
+	return users, nil
+}
+
+// UpdateUser is a mock implementation of the UpdateUser method
+func (m *PGClientMock) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name.IsSet() {
+		user.Name = *input.Name.Value()
+	}
+	if input.TagNumber.IsSet() {
+		user.TagNumber = input.TagNumber.Value()
+	}
+	return user, nil
+}
+
+// DeleteUser is a mock implementation of the DeleteUser method
+func (m *PGClientMock) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, err
+	}
+	user.Deleted = true
+	return user, nil
+}
+
+// RestoreUser is a mock implementation of the RestoreUser method
+func (m *PGClientMock) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Deleted {
+		return nil, service.NotFoundf("deleted user with Discord ID %s not found", discordID)
+	}
+	user.Deleted = false
+	return user, nil
+}
+
+// UpdateUserRole is a mock implementation of the UpdateUserRole method
+func (m *PGClientMock) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// ReviewUser is a mock implementation of the ReviewUser method; only pendingID awaits review
+func (m *PGClientMock) ReviewUser(ctx context.Context, discordID string, review service.UserReview) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != model.ApprovalStatusPending {
+		return nil, service.NotFoundf("pending user with Discord ID %s not found", discordID)
+	}
+	reviewedAt := time.Unix(0, 0).UTC()
+	user.Status, user.RejectionReason, user.ReviewedBy, user.ReviewedAt = review.Status, review.RejectionReason, review.ReviewedBy, &reviewedAt
+	return user, nil
+}
+
+// GetUserByTagNumber is a mock implementation of the GetUserByTagNumber method
+func (m *PGClientMock) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	if tagNumber == 1 {
+		return &model.User{DiscordID: "validID", Name: "Test User", TagNumber: &tagNumber, Role: service.DefaultRole, Status: model.ApprovalStatusApproved}, nil
+	}
+	return nil, service.NotFoundf("no user holds tag %d", tagNumber)
+}
+
+// GetUsersByTagNumbers is a mock implementation of the GetUsersByTagNumbers method; only tag 1 is held
+func (m *PGClientMock) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	users := make([]*model.User, len(tagNumbers))
+	for i, tagNumber := range tagNumbers {
+		users[i], _ = m.GetUserByTagNumber(ctx, tagNumber)
+	}
+	return users, nil
+}
+
+// ClaimTag is a mock implementation of the ClaimTag method; tag 1 is always taken
+func (m *PGClientMock) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, err
+	}
+	if tagNumber == 1 {
+		return nil, service.AlreadyExistsf("tag %d is already claimed by another user", tagNumber)
+	}
+	user.TagNumber = &tagNumber
+	return user, nil
+}
+
+// SwapTags is a mock implementation of the SwapTags method
+func (m *PGClientMock) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.User, *model.User, error) {
+	userA, err := m.GetUserByDiscordID(ctx, discordIDA, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	userB, err := m.GetUserByDiscordID(ctx, discordIDB, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return userA, userB, nil
+}
+
+// ReassignTags is a mock implementation of the ReassignTags method; participants are treated as
+// already holding tags 1..n in finishing order, so no tag changes hands
+func (m *PGClientMock) ReassignTags(ctx context.Context, discordIDs []string) ([]*model.TagAssignment, error) {
+	assignments := make([]*model.TagAssignment, len(discordIDs))
+	for i, id := range discordIDs {
+		user, err := m.GetUserByDiscordID(ctx, id, false)
+		if err != nil {
+			return nil, err
+		}
+		tag := i + 1
+		user.TagNumber = &tag
+		assignments[i] = &model.TagAssignment{User: user, PreviousTagNumber: tag, TagNumber: tag}
+	}
+	return assignments, nil
+}
+
+// GetTagHistoryByUser is a mock implementation of the GetTagHistoryByUser method; no tag has changed
+func (m *PGClientMock) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	return []*model.TagChange{}, nil
+}
+
+// GetTagHistoryByTag is a mock implementation of the GetTagHistoryByTag method; no tag has changed
+func (m *PGClientMock) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	return []*model.TagChange{}, nil
+}
+
+// GetTagStandings is a mock implementation of the GetTagStandings method; validID has held tag 1
+// since the Unix epoch
+func (m *PGClientMock) GetTagStandings(ctx context.Context, asOf time.Time) ([]*model.TagStanding, error) {
+	heldSince := time.Unix(0, 0).UTC()
+	if asOf.Before(heldSince) {
+		return []*model.TagStanding{}, nil
+	}
+	return []*model.TagStanding{{TagNumber: 1, DiscordID: "validID", HeldSince: heldSince}}, nil
+}
+
+// ListTagHolders is a mock implementation of the ListTagHolders method; validID is the only holder, of tag 1
+func (m *PGClientMock) ListTagHolders(ctx context.Context, afterTag, limit int) ([]*model.User, error) {
+	if afterTag >= 1 || limit < 1 {
+		return []*model.User{}, nil
+	}
+	user, err := m.GetUserByTagNumber(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []*model.User{user}, nil
+}
+
+// ListUsers is a mock implementation of the ListUsers method; it applies opts to validID and deletedID
+func (m *PGClientMock) ListUsers(ctx context.Context, opts service.ListUsersOptions) ([]*model.User, error) {
+	var roster []*model.User
+	for _, id := range []string{"validID", "deletedID"} {
+		user, err := m.GetUserByDiscordID(ctx, id, true)
+		if err != nil {
+			return nil, err
+		}
+		roster = append(roster, user)
+	}
+	return opts.Apply(roster), nil
+}
+
+// SearchUsers is a mock implementation of the SearchUsers method; validID is the only candidate
+func (m *PGClientMock) SearchUsers(ctx context.Context, query string, limit int) ([]*model.User, error) {
+	user, err := m.GetUserByDiscordID(ctx, "validID", false)
+	if err != nil {
+		return nil, err
+	}
+	return []*model.User{user}, nil
+}
+
+// ValidAPIKey authenticates as validKeyID, which holds the USERS_READ scope
+const ValidAPIKey = service.APIKeyPrefix + "validKey"
+
+// mockAPIKey returns the validKeyID fixture
+func mockAPIKey() *model.APIKey {
+	return &model.APIKey{ID: "validKeyID", Name: "Test Bot", Prefix: ValidAPIKey[:12], Scopes: []model.APIKeyScope{model.APIKeyScopeUsersRead}, CreatedAt: time.Unix(0, 0).UTC()}
+}
+
+// CreateAPIKey is a mock implementation of the CreateAPIKey method
+func (m *PGClientMock) CreateAPIKey(ctx context.Context, key *model.APIKey, hash []byte) error {
+	key.CreatedAt = time.Unix(0, 0).UTC()
+	return nil
+}
+
+// GetAPIKeyByHash is a mock implementation of the GetAPIKeyByHash method; only ValidAPIKey is known
+func (m *PGClientMock) GetAPIKeyByHash(ctx context.Context, hash []byte) (*model.APIKey, error) {
+	if sum := sha256.Sum256([]byte(ValidAPIKey)); !bytes.Equal(hash, sum[:]) {
+		return nil, service.NotFoundf("API key not found")
+	}
+	return mockAPIKey(), nil
+}
+
+// ListAPIKeys is a mock implementation of the ListAPIKeys method
+func (m *PGClientMock) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	return []*model.APIKey{mockAPIKey()}, nil
+}
+
+// RevokeAPIKey is a mock implementation of the RevokeAPIKey method
+func (m *PGClientMock) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	if id != "validKeyID" {
+		return nil, service.NotFoundf("active API key %s not found", id)
+	}
+	key := mockAPIKey()
+	revokedAt := time.Unix(0, 0).UTC()
+	key.RevokedAt = &revokedAt
+	return key, nil
+}
+
+// RelayEvents is a mock implementation of the RelayEvents method; the mock records no events
+func (m *PGClientMock) RelayEvents(ctx context.Context, limit int, publish func(context.Context, service.Event) error) (int, error) {
+	return 0, nil
 }
 
 // Close is a mock implementation of the Close method
@@ -49,7 +285,11 @@ type MockUserService struct {
 
 // CreateUser  mocks the CreateUser  method of UserService
 func (m *MockUserService) CreateUser(ctx context.Context, input model.UserInput) (*model.User, error) {
-	user := &model.User{DiscordID: input.DiscordID, Name: input.Name}
+	role := service.DefaultRole
+	if r := input.Role.Value(); r != nil {
+		role = *r
+	}
+	user := &model.User{DiscordID: input.DiscordID, Name: input.Name, TagNumber: input.TagNumber.Value(), Role: role, Status: model.ApprovalStatusApproved}
 	if err := m.PGClientMock.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
@@ -57,6 +297,108 @@ func (m *MockUserService) CreateUser(ctx context.Context, input model.UserInput)
 }
 
 // GetUser ByDiscordID mocks the GetUser ByDiscordID method of UserService
-func (m *MockUserService) GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
-	return m.PGClientMock.GetUserByDiscordID(ctx, discordID)
+func (m *MockUserService) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	return m.PGClientMock.GetUserByDiscordID(ctx, discordID, includeDeleted)
+}
+
+// UpdateUser mocks the UpdateUser method of UserService
+func (m *MockUserService) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	return m.PGClientMock.UpdateUser(ctx, discordID, input)
+}
+
+// DeleteUser mocks the DeleteUser method of UserService
+func (m *MockUserService) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	return m.PGClientMock.DeleteUser(ctx, discordID)
+}
+
+// RestoreUser mocks the RestoreUser method of UserService
+func (m *MockUserService) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	return m.PGClientMock.RestoreUser(ctx, discordID)
+}
+
+// GetUserByTagNumber mocks the GetUserByTagNumber method of UserService
+func (m *MockUserService) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	return m.PGClientMock.GetUserByTagNumber(ctx, tagNumber)
+}
+
+// ClaimTag mocks the ClaimTag method of UserService
+func (m *MockUserService) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	return m.PGClientMock.ClaimTag(ctx, discordID, tagNumber)
+}
+
+// SwapTags mocks the SwapTags method of UserService
+func (m *MockUserService) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.TagSwap, error) {
+	userA, userB, err := m.PGClientMock.SwapTags(ctx, discordIDA, discordIDB)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TagSwap{UserA: userA, UserB: userB}, nil
+}
+
+// ReassignTags mocks the ReassignTags method of UserService
+func (m *MockUserService) ReassignTags(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error) {
+	discordIDs := make([]string, len(results))
+	for i, result := range results {
+		discordIDs[i] = result.DiscordID
+	}
+	return m.PGClientMock.ReassignTags(ctx, discordIDs)
+}
+
+// GetTagHistoryByUser mocks the GetTagHistoryByUser method of UserService
+func (m *MockUserService) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	return m.PGClientMock.GetTagHistoryByUser(ctx, discordID)
+}
+
+// GetTagHistoryByTag mocks the GetTagHistoryByTag method of UserService
+func (m *MockUserService) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	return m.PGClientMock.GetTagHistoryByTag(ctx, tagNumber)
+}
+
+// GetTagStandings mocks the GetTagStandings method of UserService
+func (m *MockUserService) GetTagStandings(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error) {
+	at := time.Now()
+	if asOf != nil {
+		at = *asOf
+	}
+	return m.PGClientMock.GetTagStandings(ctx, at)
+}
+
+// GetTagLeaderboard mocks the GetTagLeaderboard method of UserService
+func (m *MockUserService) GetTagLeaderboard(ctx context.Context, first int, after *string) (*model.UserConnection, error) {
+	return service.NewUserService(m.PGClientMock).GetTagLeaderboard(ctx, first, after)
+}
+
+// ListUsers mocks the ListUsers method of UserService
+func (m *MockUserService) ListUsers(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error) {
+	return service.NewUserService(m.PGClientMock).ListUsers(ctx, filter, orderBy, first, after)
+}
+
+// SearchUsers mocks the SearchUsers method of UserService
+func (m *MockUserService) SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error) {
+	return service.NewUserService(m.PGClientMock).SearchUsers(ctx, query, limit)
+}
+
+// GetUsersByDiscordIDs mocks the GetUsersByDiscordIDs method of UserService
+func (m *MockUserService) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	return m.PGClientMock.GetUsersByDiscordIDs(ctx, discordIDs)
+}
+
+// GetUsersByTagNumbers mocks the GetUsersByTagNumbers method of UserService
+func (m *MockUserService) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	return m.PGClientMock.GetUsersByTagNumbers(ctx, tagNumbers)
+}
+
+// UpdateUserRole mocks the UpdateUserRole method of UserService
+func (m *MockUserService) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	return m.PGClientMock.UpdateUserRole(ctx, discordID, role)
+}
+
+// ApproveUser mocks the ApproveUser method of UserService
+func (m *MockUserService) ApproveUser(ctx context.Context, discordID string) (*model.User, error) {
+	return service.NewUserService(m.PGClientMock).ApproveUser(ctx, discordID)
+}
+
+// RejectUser mocks the RejectUser method of UserService
+func (m *MockUserService) RejectUser(ctx context.Context, discordID string, reason string) (*model.User, error) {
+	return service.NewUserService(m.PGClientMock).RejectUser(ctx, discordID, reason)
 }
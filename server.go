@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -15,7 +16,11 @@ import (
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/auth"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/discord"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/loaders"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/migrations"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -34,15 +39,55 @@ func main() {
 	ctx := context.Background()
 	dataSourceName := os.Getenv("DATABASE_URL") // Ensure this environment variable is set
 
-	// Create the PostgreSQL client
-	pgClient, err := service.NewPGClient(dataSourceName)
-	if err != nil {
-		log.Fatalf("Failed to create PostgreSQL client: %v", err)
+	// Create the storage client; STORAGE=memory runs the service without PostgreSQL
+	var pgClient service.PGClient
+	memoryStorage := os.Getenv("STORAGE") == "memory"
+	if memoryStorage {
+		log.Println("Using in-memory storage")
+		pgClient = service.NewMemoryClient()
+	} else {
+		pgImpl, err := service.NewPGClient(dataSourceName)
+		if err != nil {
+			log.Fatalf("Failed to create PostgreSQL client: %v", err)
+		}
+		if !runMigrations(ctx, pgImpl.Pool) {
+			pgImpl.Close(ctx)
+			return
+		}
+		pgClient = pgImpl
 	}
 	defer pgClient.Close(ctx) // Pass context here
 
 	// Create UserService
 	userService := service.NewUserService(pgClient) // Assume you have a UserService struct
+	apiKeyService := service.NewAPIKeyService(pgClient)
+
+	// Domain events wait in the outbox until a service.Publisher that reaches other services is
+	// configured; relaying them anywhere else would mark them published and lose them. The memory
+	// outbox dies with the process anyway, so there events are relayed to the log to keep it drained.
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	defer stopRelay()
+	if memoryStorage {
+		publisher := service.NewInProcessPublisher()
+		publisher.Subscribe(func(ctx context.Context, event service.Event) error {
+			log.Printf("Published %s event %d for user %s", event.Type, event.ID, event.DiscordID)
+			return nil
+		})
+		go service.NewEventRelay(pgClient, publisher).Run(relayCtx)
+	}
+
+	// Verify the tokens our bot signs for its callers
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to read JWT configuration: %v", err)
+	}
+	authenticator, err := auth.NewAuthenticator(authConfig)
+	if errors.Is(err, auth.ErrNoSigningKey) && memoryStorage {
+		// Keep zero-config local runs working; API keys still identify their clients
+		log.Println("Warning: no JWT signing key configured, so JWTs are rejected and users can only call anonymously")
+	} else if err != nil {
+		log.Fatalf("Failed to create authenticator: %v", err)
+	}
 
 	// Create a new Chi router
 	router := chi.NewRouter()
@@ -53,13 +98,29 @@ func main() {
 	router.Use(middleware.Recoverer)
 
 	// Create a new GraphQL server with the resolver that has the UserService
-	gqlServer := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
-		UserService: userService,
-	}}))
+	gqlServer := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{
+			UserService:   userService,
+			APIKeyService: apiKeyService,
+		},
+		Directives: graph.Directives(),
+	}))
+	gqlServer.SetErrorPresenter(graph.ErrorPresenter)
 
 	// Set up routes
 	router.Handle("/", playground.Handler("GraphQL playground", "/graphql"))
-	router.Handle("/graphql", gqlServer)
+	// Each GraphQL request gets its own dataloader, batching and caching user lookups,
+	// and carries the caller identified by its bearer token or API key for @hasRole and @hasScope
+	router.With(loaders.Middleware(userService), auth.Middleware(authenticator, userService, apiKeyService)).Handle("/graphql", gqlServer)
+
+	// Discord posts slash commands here once DISCORD_PUBLIC_KEY is set; requests are verified by signature
+	if encoded := os.Getenv("DISCORD_PUBLIC_KEY"); encoded != "" {
+		publicKey, err := discord.ParsePublicKey(encoded)
+		if err != nil {
+			log.Fatalf("Invalid DISCORD_PUBLIC_KEY: %v", err)
+		}
+		router.Method(http.MethodPost, "/interactions", discord.NewHandler(publicKey, userService))
+	}
 
 	// Health check endpoint
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -83,6 +144,7 @@ func main() {
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 
+	stopRelay()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := httpServer.Shutdown(ctx); err != nil {
@@ -90,3 +152,33 @@ func main() {
 	}
 	log.Println("Server exiting")
 }
+
+// runMigrations applies or rolls back schema migrations when RUN_MIGRATIONS is set,
+// and reports whether the server should keep starting
+func runMigrations(ctx context.Context, db migrations.DB) bool {
+	mode := os.Getenv("RUN_MIGRATIONS")
+	if mode == "" {
+		return true
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	switch mode {
+	case "up":
+		if _, err := migrator.Up(ctx); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		return true
+	case "down":
+		// Roll back the latest migration and exit without serving
+		if _, err := migrator.Down(ctx, 1); err != nil {
+			log.Fatalf("Failed to roll back migration: %v", err)
+		}
+		return false
+	default:
+		log.Fatalf("Unknown RUN_MIGRATIONS mode %q, expected up or down", mode)
+		return false
+	}
+}
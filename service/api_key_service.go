@@ -0,0 +1,149 @@
+// service/api_key_service.go
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from JWTs
+const APIKeyPrefix = "tcr_"
+
+// apiKeyBytes is the amount of randomness in an API key's secret
+const apiKeyBytes = 32
+
+// apiKeyPrefixLength is how much of a key is kept in the clear so admins can recognize it
+const apiKeyPrefixLength = len(APIKeyPrefix) + 8
+
+// APIKeyService manages the API keys internal clients such as the bot authenticate with
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, input model.APIKeyInput) (*model.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+// APIKeyServiceImpl is the concrete implementation of APIKeyService. Only admins may manage keys.
+type APIKeyServiceImpl struct {
+	Client PGClient
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(client PGClient) *APIKeyServiceImpl {
+	return &APIKeyServiceImpl{Client: client}
+}
+
+// CreateAPIKey issues a new API key. The key is returned only here; just its hash is stored.
+func (s *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, input model.APIKeyInput) (*model.CreatedAPIKey, error) {
+	if !CallerFromContext(ctx).IsAdmin() {
+		return nil, Forbiddenf("managing API keys requires the %s role", RoleAdmin)
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, InvalidInputf("input.name", "Name is required")
+	}
+	if len(input.Scopes) == 0 {
+		return nil, InvalidInputf("input.scopes", "At least one scope is required")
+	}
+	scopes := make([]model.APIKeyScope, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		if !scope.IsValid() {
+			return nil, InvalidInputf("input.scopes", "invalid scope %q", scope)
+		}
+		if !containsScope(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, Internal("failed to generate API key", err)
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &model.APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Prefix:    key[:apiKeyPrefixLength],
+		Scopes:    scopes,
+		CreatedBy: actorDiscordID(ctx),
+	}
+	if err := s.Client.CreateAPIKey(ctx, apiKey, hashAPIKey(key)); err != nil {
+		return nil, wrapClientError("failed to create API key", err)
+	}
+
+	return &model.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys lists the API keys oldest first, including revoked keys if asked
+func (s *APIKeyServiceImpl) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	if !CallerFromContext(ctx).IsAdmin() {
+		return nil, Forbiddenf("managing API keys requires the %s role", RoleAdmin)
+	}
+
+	keys, err := s.Client.ListAPIKeys(ctx, includeRevoked)
+	if err != nil {
+		return nil, wrapClientError("failed to list API keys", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key so it can no longer authenticate
+func (s *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	if !CallerFromContext(ctx).IsAdmin() {
+		return nil, Forbiddenf("managing API keys requires the %s role", RoleAdmin)
+	}
+	if id == "" {
+		return nil, InvalidInputf("id", "ID is required")
+	}
+
+	key, err := s.Client.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, wrapClientError("failed to revoke API key", err)
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey returns the unrevoked API key matching key. Unknown and revoked keys are
+// reported as CodeUnauthenticated.
+func (s *APIKeyServiceImpl) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, Unauthenticatedf("invalid API key")
+	}
+
+	apiKey, err := s.Client.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, ErrNotFound) {
+		return nil, Unauthenticatedf("invalid API key")
+	}
+	if err != nil {
+		return nil, wrapClientError("failed to authenticate API key", err)
+	}
+
+	return apiKey, nil
+}
+
+// hashAPIKey returns the hash an API key is stored under. Keys are random, so a fast hash is enough.
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// containsScope reports whether scopes contains scope
+func containsScope(scopes []model.APIKeyScope, scope model.APIKeyScope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+func TestAPIKeyServiceImpl_Lifecycle(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(service.NewMemoryClient())
+	ctx := context.Background()
+	adminCtx := service.WithCaller(ctx, &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+
+	input := model.APIKeyInput{Name: " scoring ", Scopes: []model.APIKeyScope{model.APIKeyScopeTagsWrite, model.APIKeyScopeTagsWrite, model.APIKeyScopeUsersRead}}
+	created, err := apiKeys.CreateAPIKey(adminCtx, input)
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(created.Key, service.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.APIKey.Prefix) {
+		t.Errorf("CreateAPIKey() key = %q with prefix %q", created.Key, created.APIKey.Prefix)
+	}
+	if created.APIKey.Name != "scoring" {
+		t.Errorf("CreateAPIKey() name = %q, want scoring", created.APIKey.Name)
+	}
+	wantScopes := []model.APIKeyScope{model.APIKeyScopeTagsWrite, model.APIKeyScopeUsersRead}
+	if !reflect.DeepEqual(created.APIKey.Scopes, wantScopes) {
+		t.Errorf("CreateAPIKey() scopes = %v, want %v", created.APIKey.Scopes, wantScopes)
+	}
+	if by := created.APIKey.CreatedBy; by == nil || *by != "admin" {
+		t.Errorf("CreateAPIKey() createdBy = %v, want admin", by)
+	}
+
+	authenticated, err := apiKeys.AuthenticateAPIKey(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey() error = %v", err)
+	}
+	if authenticated.ID != created.APIKey.ID {
+		t.Errorf("AuthenticateAPIKey() ID = %s, want %s", authenticated.ID, created.APIKey.ID)
+	}
+
+	if _, err := apiKeys.RevokeAPIKey(adminCtx, created.APIKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+	if _, err := apiKeys.AuthenticateAPIKey(ctx, created.Key); service.ErrorCodeOf(err) != service.CodeUnauthenticated {
+		t.Errorf("AuthenticateAPIKey() revoked key error = %v, want UNAUTHENTICATED", err)
+	}
+	if _, err := apiKeys.RevokeAPIKey(adminCtx, created.APIKey.ID); service.ErrorCodeOf(err) != service.CodeNotFound {
+		t.Errorf("RevokeAPIKey() twice error = %v, want NOT_FOUND", err)
+	}
+
+	active, err := apiKeys.ListAPIKeys(adminCtx, false)
+	if err != nil || len(active) != 0 {
+		t.Errorf("ListAPIKeys() = %v, %v, want no active keys", active, err)
+	}
+	all, err := apiKeys.ListAPIKeys(adminCtx, true)
+	if err != nil || len(all) != 1 || all[0].RevokedAt == nil {
+		t.Errorf("ListAPIKeys(includeRevoked) = %v, %v, want the revoked key", all, err)
+	}
+}
+
+func TestAPIKeyServiceImpl_CreateAPIKey_Validation(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(service.NewMemoryClient())
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+	botCtx := service.WithCaller(context.Background(), &service.Caller{APIKeyID: "keyID", Scopes: model.AllAPIKeyScope})
+	scopes := []model.APIKeyScope{model.APIKeyScopeUsersRead}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		input    model.APIKeyInput
+		wantCode service.ErrorCode
+	}{
+		{"Editor", editorCtx, model.APIKeyInput{Name: "bot", Scopes: scopes}, service.CodeForbidden},
+		{"API_Key", botCtx, model.APIKeyInput{Name: "bot", Scopes: scopes}, service.CodeForbidden},
+		{"Missing_Name", adminCtx, model.APIKeyInput{Name: " ", Scopes: scopes}, service.CodeInvalidInput},
+		{"No_Scopes", adminCtx, model.APIKeyInput{Name: "bot"}, service.CodeInvalidInput},
+		{"Invalid_Scope", adminCtx, model.APIKeyInput{Name: "bot", Scopes: []model.APIKeyScope{"users:admin"}}, service.CodeInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := apiKeys.CreateAPIKey(tt.ctx, tt.input); service.ErrorCodeOf(err) != tt.wantCode {
+				t.Errorf("CreateAPIKey() error = %v, want %v", err, tt.wantCode)
+			}
+		})
+	}
+
+	if _, err := apiKeys.AuthenticateAPIKey(context.Background(), "not-a-key"); service.ErrorCodeOf(err) != service.CodeUnauthenticated {
+		t.Errorf("AuthenticateAPIKey() malformed key error = %v, want UNAUTHENTICATED", err)
+	}
+}
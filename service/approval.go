@@ -0,0 +1,35 @@
+// service/approval.go
+
+package service
+
+import (
+	"context"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// UserReview is the outcome of reviewing a pending user
+type UserReview struct {
+	Status          model.ApprovalStatus // APPROVED or REJECTED
+	RejectionReason *string              // Set when rejected
+	ReviewedBy      *string              // Discord ID of the reviewer, if known
+}
+
+// checkCanHoldTag refuses to give a tag to a user whose registration is not approved, since tag
+// lookups and listings leave such users out and the tag would be stuck
+func checkCanHoldTag(user *model.User) error {
+	if user.Status != model.ApprovalStatusApproved {
+		return Forbiddenf("user with Discord ID %s can hold a tag once their registration is approved", user.DiscordID)
+	}
+	return nil
+}
+
+// initialStatus returns the approval status of a user created by the caller in ctx: users that
+// Editors or internal clients create are approved at once, while everyone else awaits review
+func initialStatus(ctx context.Context) model.ApprovalStatus {
+	caller := CallerFromContext(ctx)
+	if caller.HasRole(RoleEditor) || caller.IsAPIKey() {
+		return model.ApprovalStatusApproved
+	}
+	return model.ApprovalStatusPending
+}
@@ -0,0 +1,60 @@
+// service/caller.go
+
+package service
+
+import (
+	"context"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// Caller identifies who is making a request: a user, or an internal client holding an API key
+type Caller struct {
+	DiscordID string
+	Role      model.Role           // Empty unless the caller is an approved user
+	Status    model.ApprovalStatus // Approval status of the caller's user; empty if they have none yet
+	GuildID   string               // Discord guild the request came from, if known
+
+	APIKeyID string              // Set instead of DiscordID for API key clients
+	Scopes   []model.APIKeyScope // What the API key may do
+}
+
+// callerKey is the context key under which the Caller is stored
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx carrying the given caller
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller stored in ctx, or nil for anonymous requests
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// IsAdmin reports whether the caller holds the Admin role
+func (c *Caller) IsAdmin() bool {
+	return c != nil && c.Role == RoleAdmin
+}
+
+// HasRole reports whether the caller holds min or a more privileged role
+func (c *Caller) HasRole(min model.Role) bool {
+	return c != nil && c.Role.AtLeast(min)
+}
+
+// CanActOnSelf reports whether the caller may act on their own user: approved users, and newcomers
+// without a user who are registering themselves. Users awaiting or denied approval may not.
+func (c *Caller) CanActOnSelf() bool {
+	return c != nil && c.DiscordID != "" && (c.Status == "" || c.Status == model.ApprovalStatusApproved)
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key rather than as a user
+func (c *Caller) IsAPIKey() bool {
+	return c != nil && c.APIKeyID != ""
+}
+
+// HasScope reports whether the caller's API key holds scope
+func (c *Caller) HasScope(scope model.APIKeyScope) bool {
+	return c.IsAPIKey() && containsScope(c.Scopes, scope)
+}
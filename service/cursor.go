@@ -0,0 +1,103 @@
+// service/cursor.go
+
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// maxPageSize caps the first argument of connection queries
+const maxPageSize = 100
+
+// encodeCursor builds an opaque cursor from a keyset position; kind stops a cursor from
+// one connection being replayed against another
+func encodeCursor(kind, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + key))
+}
+
+// decodeCursor returns the keyset position in a cursor made by encodeCursor with the same kind
+func decodeCursor(kind, cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	key, ok := strings.CutPrefix(string(raw), kind+":")
+	if err != nil || !ok {
+		return "", InvalidInputf("after", "invalid cursor")
+	}
+	return key, nil
+}
+
+// encodeTagCursor returns the cursor positioned after a tag in the leaderboard
+func encodeTagCursor(tagNumber int) string {
+	return encodeCursor("tag", strconv.Itoa(tagNumber))
+}
+
+// decodeTagCursor returns the tag a leaderboard cursor is positioned after
+func decodeTagCursor(cursor string) (int, error) {
+	key, err := decodeCursor("tag", cursor)
+	if err != nil {
+		return 0, err
+	}
+	tagNumber, err := strconv.Atoi(key)
+	if err != nil || tagNumber <= 0 {
+		return 0, InvalidInputf("after", "invalid cursor")
+	}
+	return tagNumber, nil
+}
+
+// userCursor is the keyset position of a user in the users query, valid for any ordering
+type userCursor struct {
+	DiscordID string    `json:"d"`
+	Name      string    `json:"n"`
+	CreatedAt time.Time `json:"c"`
+}
+
+// encodeUserCursor returns the cursor positioned after user in the users query
+func encodeUserCursor(user *model.User) string {
+	key, _ := json.Marshal(userCursor{DiscordID: user.DiscordID, Name: user.Name, CreatedAt: user.CreatedAt})
+	return encodeCursor("user", string(key))
+}
+
+// decodeUserCursor returns the position a users cursor is at, as a user holding only the sort keys
+func decodeUserCursor(cursor string) (*model.User, error) {
+	key, err := decodeCursor("user", cursor)
+	if err != nil {
+		return nil, err
+	}
+	var position userCursor
+	if err := json.Unmarshal([]byte(key), &position); err != nil || position.DiscordID == "" {
+		return nil, InvalidInputf("after", "invalid cursor")
+	}
+	return &model.User{DiscordID: position.DiscordID, Name: position.Name, CreatedAt: position.CreatedAt}, nil
+}
+
+// validatePageSize rejects a first argument outside 1..maxPageSize
+func validatePageSize(first int) error {
+	if first < 1 || first > maxPageSize {
+		return InvalidInputf("first", "First must be between 1 and %d", maxPageSize)
+	}
+	return nil
+}
+
+// newUserConnection builds a page from up to first+1 users, using the extra user only to
+// report whether another page follows
+func newUserConnection(users []*model.User, first int, cursorOf func(*model.User) string) *model.UserConnection {
+	pageInfo := &model.PageInfo{HasNextPage: len(users) > first}
+	if pageInfo.HasNextPage {
+		users = users[:first]
+	}
+
+	edges := make([]*model.UserEdge, len(users))
+	for i, user := range users {
+		edges[i] = &model.UserEdge{Cursor: cursorOf(user), Node: user}
+	}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+	return &model.UserConnection{Edges: edges, PageInfo: pageInfo}
+}
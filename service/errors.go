@@ -0,0 +1,111 @@
+// service/errors.go
+
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies a service error so clients can react without matching messages
+type ErrorCode string
+
+// Error codes surfaced to clients in extensions.code
+const (
+	CodeNotFound        ErrorCode = "NOT_FOUND"
+	CodeAlreadyExists   ErrorCode = "ALREADY_EXISTS"
+	CodeInvalidInput    ErrorCode = "INVALID_INPUT"
+	CodeUnauthenticated ErrorCode = "UNAUTHENTICATED"
+	CodeForbidden       ErrorCode = "FORBIDDEN"
+	CodeConflict        ErrorCode = "CONFLICT"
+	CodeInternal        ErrorCode = "INTERNAL"
+)
+
+// ErrNotFound is the not-found sentinel; every single-user PGClient and UserService method returns
+// an error matching it with errors.Is when the requested user does not exist
+var ErrNotFound = &Error{Code: CodeNotFound, Message: "not found"}
+
+// Error is a typed service error
+type Error struct {
+	Code    ErrorCode
+	Message string // Safe to show to clients, except for CodeInternal
+	Field   string // Path of the offending argument or input field, if any
+	Err     error  // Underlying cause, never shown to clients
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches service errors by code, so errors.Is(err, ErrNotFound) holds for any not-found error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// NotFoundf reports a missing resource
+func NotFoundf(format string, args ...any) *Error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// AlreadyExistsf reports a conflict with an existing resource
+func AlreadyExistsf(format string, args ...any) *Error {
+	return &Error{Code: CodeAlreadyExists, Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidInputf reports a rejected argument; field is its path, e.g. "input.name"
+func InvalidInputf(field, format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidInput, Message: fmt.Sprintf(format, args...), Field: field}
+}
+
+// Unauthenticatedf reports that the operation needs a known caller and the request has none
+func Unauthenticatedf(format string, args ...any) *Error {
+	return &Error{Code: CodeUnauthenticated, Message: fmt.Sprintf(format, args...)}
+}
+
+// Forbiddenf reports that the caller may not perform the operation
+func Forbiddenf(format string, args ...any) *Error {
+	return &Error{Code: CodeForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflictf reports that the data changed underneath the operation and it should be retried
+func Conflictf(format string, args ...any) *Error {
+	return &Error{Code: CodeConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Internal wraps an unexpected failure such as a database error
+func Internal(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// tagTakenError reports a bag tag already held by another user
+func tagTakenError(tagNumber int) *Error {
+	return &Error{Code: CodeAlreadyExists, Message: fmt.Sprintf("tag %d is already claimed by another user", tagNumber), Field: "tagNumber"}
+}
+
+// ErrorCodeOf returns the code of the first service error in err's chain, or CodeInternal
+func ErrorCodeOf(err error) ErrorCode {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr.Code
+	}
+	return CodeInternal
+}
+
+// wrapClientError passes typed errors from a PGClient through and wraps anything else as internal
+func wrapClientError(message string, err error) error {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return err
+	}
+	return Internal(message, err)
+}
@@ -0,0 +1,60 @@
+// service/event_relay.go
+
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Defaults for NewEventRelay
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatchSize = 100
+)
+
+// EventRelay moves domain events from the outbox to a Publisher in the background.
+// An event leaves the outbox only once it has been published, so a publisher outage delays
+// events rather than losing them.
+type EventRelay struct {
+	Client    PGClient
+	Publisher Publisher
+	Interval  time.Duration // How often to poll the outbox
+	BatchSize int           // Most events published per outbox transaction
+}
+
+// NewEventRelay creates an EventRelay polling every second in batches of 100
+func NewEventRelay(client PGClient, publisher Publisher) *EventRelay {
+	return &EventRelay{Client: client, Publisher: publisher, Interval: defaultRelayInterval, BatchSize: defaultRelayBatchSize}
+}
+
+// Run relays events every Interval until ctx is done. Start it in its own goroutine.
+func (r *EventRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error relaying events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes pending events in batches until the outbox is drained or publishing fails,
+// and returns how many events it published
+func (r *EventRelay) Flush(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := r.Client.RelayEvents(ctx, r.BatchSize, r.Publisher.Publish)
+		total += published
+		if err != nil || published < r.BatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+func TestEventRelay_Flush(t *testing.T) {
+	client := service.NewMemoryClient()
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+	tag := 1
+	if err := client.CreateUser(ctx, &model.User{DiscordID: "alice", Name: "Alice", TagNumber: &tag, Role: service.RoleRattler}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	name := "Alicia"
+	if _, err := client.UpdateUser(ctx, "alice", model.UpdateUserInput{Name: graphql.OmittableOf(&name)}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if _, err := client.UpdateUserRole(ctx, "alice", service.RoleEditor); err != nil {
+		t.Fatalf("UpdateUserRole() error = %v", err)
+	}
+
+	publisher := service.NewInProcessPublisher()
+	fail := true
+	publisher.Subscribe(func(ctx context.Context, event service.Event) error {
+		if event.Type == service.EventUserUpdated && fail {
+			return errors.New("subscriber unavailable")
+		}
+		return nil
+	})
+	// Record what got through, after the failing subscriber has had its say
+	var events []service.Event
+	publisher.Subscribe(func(ctx context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	relay := service.NewEventRelay(client, publisher)
+	relay.BatchSize = 1
+
+	// Delivery stops at the failing event so later events are not published out of order
+	published, err := relay.Flush(context.Background())
+	if err == nil || published != 2 {
+		t.Fatalf("Flush() = %d, %v, want 2 and an error", published, err)
+	}
+
+	fail = false
+	published, err = relay.Flush(context.Background())
+	if err != nil || published != 2 {
+		t.Fatalf("Flush() retry = %d, %v, want 2, nil", published, err)
+	}
+	if published, err := relay.Flush(context.Background()); err != nil || published != 0 {
+		t.Errorf("Flush() drained outbox = %d, %v, want 0, nil", published, err)
+	}
+
+	want := []service.EventType{service.EventUserCreated, service.EventTagChanged, service.EventUserUpdated, service.EventRoleChanged}
+	if len(events) != len(want) {
+		t.Fatalf("published %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Type != want[i] || event.DiscordID != "alice" {
+			t.Errorf("event %d = %s for %s, want %s for alice", i, event.Type, event.DiscordID, want[i])
+		}
+	}
+
+	var roleChanged service.RoleChangedEvent
+	if err := json.Unmarshal(events[3].Payload, &roleChanged); err != nil {
+		t.Fatalf("RoleChanged payload error = %v", err)
+	}
+	if roleChanged.PreviousRole != service.RoleRattler || roleChanged.Role != service.RoleEditor || roleChanged.ActorDiscordID == nil || *roleChanged.ActorDiscordID != "admin" {
+		t.Errorf("RoleChanged payload = %+v, want Rattler -> Editor by admin", roleChanged)
+	}
+
+	// Events recorded after the outbox was drained keep numbering on from the published ones
+	if _, err := client.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if published, err := relay.Flush(context.Background()); err != nil || published != 2 {
+		t.Fatalf("Flush() after delete = %d, %v, want 2, nil", published, err)
+	}
+	for i, event := range events {
+		if event.ID != int64(i+1) {
+			t.Errorf("event %d ID = %d, want %d", i, event.ID, i+1)
+		}
+	}
+}
+
+func TestMemoryClient_UpdateUser_EventPayload(t *testing.T) {
+	client := service.NewMemoryClient()
+	ctx := context.Background()
+	if err := client.CreateUser(ctx, &model.User{DiscordID: "alice", Name: "Alice", Role: service.RoleRattler, Status: model.ApprovalStatusApproved}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// Renaming and claiming a tag together reports the user as stored after both changes
+	name, tag := "Alicia", 4
+	if _, err := client.UpdateUser(ctx, "alice", model.UpdateUserInput{Name: graphql.OmittableOf(&name), TagNumber: graphql.OmittableOf(&tag)}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	var updated []service.UserEvent
+	_, err := client.RelayEvents(ctx, 10, func(ctx context.Context, event service.Event) error {
+		if event.Type == service.EventUserUpdated {
+			var payload service.UserEvent
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				return err
+			}
+			updated = append(updated, payload)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RelayEvents() error = %v", err)
+	}
+	if len(updated) != 1 {
+		t.Fatalf("relayed %d UserUpdated events, want 1", len(updated))
+	}
+	if user := updated[0].User; user.Name != name || user.TagNumber == nil || *user.TagNumber != tag {
+		t.Errorf("UserUpdated payload = %+v, want %s holding tag %d", user, name, tag)
+	}
+}
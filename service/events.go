@@ -0,0 +1,105 @@
+// service/events.go
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// EventType names a domain event other services can react to
+type EventType string
+
+const (
+	EventUserCreated EventType = "UserCreated" // Payload is a UserEvent
+	EventUserUpdated EventType = "UserUpdated" // Payload is a UserEvent; sent on renames, deletion, restores and reviews
+	EventTagChanged  EventType = "TagChanged"  // Payload is a TagChangedEvent
+	EventRoleChanged EventType = "RoleChanged" // Payload is a RoleChangedEvent
+)
+
+// Event is a domain event recorded in the outbox in the same transaction as the change it describes
+type Event struct {
+	ID         int64 // Outbox position; events are published in ID order
+	Type       EventType
+	DiscordID  string          // The user the event is about
+	Payload    json.RawMessage // JSON encoding of the payload type documented on Type
+	OccurredAt time.Time
+}
+
+// UserEvent is the payload of UserCreated and UserUpdated: the user as stored after the change
+type UserEvent struct {
+	User           *model.User `json:"user"`
+	ActorDiscordID *string     `json:"actorDiscordID,omitempty"`
+}
+
+// TagChangedEvent is the payload of TagChanged, mirroring the tag history entry it was recorded with
+type TagChangedEvent struct {
+	DiscordID         string                `json:"discordID"`
+	PreviousTagNumber *int                  `json:"previousTagNumber,omitempty"`
+	TagNumber         *int                  `json:"tagNumber,omitempty"`
+	Reason            model.TagChangeReason `json:"reason"`
+	ActorDiscordID    *string               `json:"actorDiscordID,omitempty"`
+}
+
+// RoleChangedEvent is the payload of RoleChanged
+type RoleChangedEvent struct {
+	DiscordID      string     `json:"discordID"`
+	PreviousRole   model.Role `json:"previousRole"`
+	Role           model.Role `json:"role"`
+	ActorDiscordID *string    `json:"actorDiscordID,omitempty"`
+}
+
+// userEvent builds the UserCreated or UserUpdated payload for a change made by the caller in ctx.
+// The user's tag history is left out; TagChanged events carry it.
+func userEvent(ctx context.Context, user *model.User) UserEvent {
+	snapshot := *user
+	snapshot.TagHistory = nil
+	return UserEvent{User: &snapshot, ActorDiscordID: actorDiscordID(ctx)}
+}
+
+// Publisher delivers domain events to other services. Events are delivered at least once, so
+// subscribers should treat an Event.ID they have already seen as a duplicate.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventHandler receives events from an InProcessPublisher
+type EventHandler func(ctx context.Context, event Event) error
+
+// InProcessPublisher hands events to handlers in the same process. It suits tests and
+// single-process deployments.
+type InProcessPublisher struct {
+	mu       sync.Mutex
+	handlers []EventHandler
+}
+
+// NewInProcessPublisher creates an InProcessPublisher without handlers
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{}
+}
+
+// Subscribe registers a handler for every event published from now on
+func (p *InProcessPublisher) Subscribe(handler EventHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// Publish passes the event to each handler in subscription order. If a handler fails, the event
+// counts as unpublished and the error is returned so the relay retries it.
+func (p *InProcessPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	handlers := append([]EventHandler(nil), p.handlers...)
+	p.mu.Unlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,572 @@
+// service/memory_client.go
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// MemoryClient is a thread-safe in-memory PGClient for tests and local development.
+// It enforces the same uniqueness and not-found behaviour as PGClientImpl.
+type MemoryClient struct {
+	mu        sync.RWMutex
+	users     map[string]*model.User
+	history   []*model.TagChange
+	apiKeys   []*model.APIKey   // In creation order
+	keyHashes map[string]string // API key ID by secret hash
+	outbox    []Event           // Unpublished events, oldest first
+	lastEvent int64             // ID of the most recently recorded event
+	relayMu   sync.Mutex        // Serializes RelayEvents without holding mu while publishing
+}
+
+// NewMemoryClient creates an empty MemoryClient
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{users: make(map[string]*model.User), keyHashes: make(map[string]string)}
+}
+
+// copyUser returns a deep copy so callers never share state with the store
+func copyUser(user *model.User) *model.User {
+	c := *user
+	c.TagNumber = copyInt(user.TagNumber)
+	return &c
+}
+
+// copyInt returns a pointer to a copy of *p, or nil
+func copyInt(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// CreateUser stores a new user and sets its CreatedAt, rejecting duplicate Discord IDs
+func (mc *MemoryClient) CreateUser(ctx context.Context, user *model.User) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if _, ok := mc.users[user.DiscordID]; ok {
+		return AlreadyExistsf("user with Discord ID %s already exists", user.DiscordID)
+	}
+	if user.TagNumber != nil && mc.tagHolder(*user.TagNumber) != nil {
+		return tagTakenError(*user.TagNumber)
+	}
+	user.CreatedAt = time.Now()
+	mc.users[user.DiscordID] = copyUser(user)
+	mc.recordEvent(EventUserCreated, user.DiscordID, userEvent(ctx, user))
+	mc.recordTagChange(ctx, user.DiscordID, nil, user.TagNumber, model.TagChangeReasonClaim)
+	return nil
+}
+
+// GetUserByDiscordID retrieves a user by Discord ID, skipping soft-deleted users unless includeDeleted is set
+func (mc *MemoryClient) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || (user.Deleted && !includeDeleted) {
+		return nil, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	return copyUser(user), nil
+}
+
+// GetUsersByDiscordIDs retrieves active users in input order, with nil for each ID with no active user
+func (mc *MemoryClient) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	users := make([]*model.User, len(discordIDs))
+	for i, id := range discordIDs {
+		if user, ok := mc.users[id]; ok && !user.Deleted {
+			users[i] = copyUser(user)
+		}
+	}
+	return users, nil
+}
+
+// UpdateUser applies the fields set in input to an active user
+func (mc *MemoryClient) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || user.Deleted {
+		return nil, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	if tag := input.TagNumber.Value(); tag != nil && !equalTags(tag, user.TagNumber) {
+		if err := checkCanHoldTag(user); err != nil {
+			return nil, err
+		}
+		if holder := mc.tagHolder(*tag); holder != nil && holder != user {
+			return nil, tagTakenError(*tag)
+		}
+	}
+	// Apply every field before recording events so their payloads show the updated user, as in PostgreSQL
+	previousName, previousTag := user.Name, user.TagNumber
+	if name := input.Name.Value(); name != nil {
+		user.Name = *name
+	}
+	if input.TagNumber.IsSet() {
+		user.TagNumber = copyInt(input.TagNumber.Value())
+	}
+
+	if user.Name != previousName {
+		mc.recordEvent(EventUserUpdated, discordID, userEvent(ctx, user))
+	}
+	reason := model.TagChangeReasonClaim
+	if user.TagNumber == nil {
+		reason = model.TagChangeReasonRelease
+	}
+	mc.recordTagChange(ctx, discordID, previousTag, user.TagNumber, reason)
+	return copyUser(user), nil
+}
+
+// DeleteUser soft-deletes an active user, releasing their tag so others can claim it
+func (mc *MemoryClient) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || user.Deleted {
+		return nil, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	previous := user.TagNumber
+	user.Deleted = true
+	user.TagNumber = nil
+	mc.recordEvent(EventUserUpdated, discordID, userEvent(ctx, user))
+	mc.recordTagChange(ctx, discordID, previous, nil, model.TagChangeReasonRelease)
+	return copyUser(user), nil
+}
+
+// RestoreUser restores a soft-deleted user. Their released tag is not given back.
+func (mc *MemoryClient) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || !user.Deleted {
+		return nil, NotFoundf("deleted user with Discord ID %s not found", discordID)
+	}
+	user.Deleted = false
+	mc.recordEvent(EventUserUpdated, discordID, userEvent(ctx, user))
+	return copyUser(user), nil
+}
+
+// UpdateUserRole sets the role of an active user
+func (mc *MemoryClient) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || user.Deleted {
+		return nil, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	if user.Role != role {
+		mc.recordEvent(EventRoleChanged, discordID, RoleChangedEvent{
+			DiscordID: discordID, PreviousRole: user.Role, Role: role, ActorDiscordID: actorDiscordID(ctx),
+		})
+	}
+	user.Role = role
+	return copyUser(user), nil
+}
+
+// ReviewUser records the outcome of reviewing an active, pending user. Rejecting a user releases any
+// tag they hold.
+func (mc *MemoryClient) ReviewUser(ctx context.Context, discordID string, review UserReview) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || user.Deleted || user.Status != model.ApprovalStatusPending {
+		return nil, NotFoundf("pending user with Discord ID %s not found", discordID)
+	}
+	now := time.Now()
+	user.Status = review.Status
+	user.RejectionReason = review.RejectionReason
+	user.ReviewedBy = review.ReviewedBy
+	user.ReviewedAt = &now
+	previous := user.TagNumber
+	if review.Status == model.ApprovalStatusRejected {
+		user.TagNumber = nil
+	}
+	mc.recordEvent(EventUserUpdated, discordID, userEvent(ctx, user))
+	mc.recordTagChange(ctx, discordID, previous, user.TagNumber, model.TagChangeReasonRelease)
+	return copyUser(user), nil
+}
+
+// GetUserByTagNumber retrieves the active, approved user holding a bag tag
+func (mc *MemoryClient) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	user := mc.tagHolder(tagNumber)
+	if user == nil || !approvedMember(user) {
+		return nil, NotFoundf("no user holds tag %d", tagNumber)
+	}
+	return copyUser(user), nil
+}
+
+// GetUsersByTagNumbers retrieves the active, approved holders of tags in input order, with nil for
+// each tag no such user holds
+func (mc *MemoryClient) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	users := make([]*model.User, len(tagNumbers))
+	for i, tagNumber := range tagNumbers {
+		if user := mc.tagHolder(tagNumber); user != nil && approvedMember(user) {
+			users[i] = copyUser(user)
+		}
+	}
+	return users, nil
+}
+
+// ClaimTag assigns an unclaimed bag tag to an active user, releasing any tag the user held before
+func (mc *MemoryClient) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	user, ok := mc.users[discordID]
+	if !ok || user.Deleted {
+		return nil, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	if equalTags(user.TagNumber, &tagNumber) {
+		return copyUser(user), nil
+	}
+	if err := checkCanHoldTag(user); err != nil {
+		return nil, err
+	}
+	if holder := mc.tagHolder(tagNumber); holder != nil && holder != user {
+		return nil, tagTakenError(tagNumber)
+	}
+	mc.recordTagChange(ctx, discordID, user.TagNumber, &tagNumber, model.TagChangeReasonClaim)
+	user.TagNumber = &tagNumber
+	return copyUser(user), nil
+}
+
+// SwapTags exchanges the tags of two active users
+func (mc *MemoryClient) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.User, *model.User, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	userA, ok := mc.users[discordIDA]
+	if !ok || userA.Deleted {
+		return nil, nil, NotFoundf("user with Discord ID %s not found", discordIDA)
+	}
+	userB, ok := mc.users[discordIDB]
+	if !ok || userB.Deleted {
+		return nil, nil, NotFoundf("user with Discord ID %s not found", discordIDB)
+	}
+	if userA.TagNumber == nil {
+		return nil, nil, InvalidInputf("discordIDA", "user with Discord ID %s has no tag", discordIDA)
+	}
+	if userB.TagNumber == nil {
+		return nil, nil, InvalidInputf("discordIDB", "user with Discord ID %s has no tag", discordIDB)
+	}
+	for _, user := range []*model.User{userA, userB} {
+		if err := checkCanHoldTag(user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mc.recordTagChange(ctx, discordIDA, userA.TagNumber, userB.TagNumber, model.TagChangeReasonSwap)
+	mc.recordTagChange(ctx, discordIDB, userB.TagNumber, userA.TagNumber, model.TagChangeReasonSwap)
+	userA.TagNumber, userB.TagNumber = userB.TagNumber, userA.TagNumber
+	return copyUser(userA), copyUser(userB), nil
+}
+
+// ReassignTags redistributes the participants' tags by finishing order
+func (mc *MemoryClient) ReassignTags(ctx context.Context, discordIDs []string) ([]*model.TagAssignment, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	current := make(map[string]*model.User, len(discordIDs))
+	for _, id := range discordIDs {
+		if user, ok := mc.users[id]; ok && !user.Deleted {
+			current[id] = user
+		}
+	}
+	before, err := participantTags(discordIDs, current)
+	if err != nil {
+		return nil, err
+	}
+	after := redistributeTags(before)
+
+	assignments := make([]*model.TagAssignment, len(discordIDs))
+	for i, id := range discordIDs {
+		tag := after[i]
+		mc.recordTagChange(ctx, id, &before[i], &tag, model.TagChangeReasonReassignment)
+		current[id].TagNumber = &tag
+		assignments[i] = &model.TagAssignment{User: copyUser(current[id]), PreviousTagNumber: before[i], TagNumber: tag}
+	}
+	return assignments, nil
+}
+
+// GetTagHistoryByUser lists every change to a user's tag, oldest first
+func (mc *MemoryClient) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	return mc.filterHistory(func(change *model.TagChange) bool {
+		return change.DiscordID == discordID
+	}), nil
+}
+
+// GetTagHistoryByTag lists every change that gave out or took away a tag, oldest first
+func (mc *MemoryClient) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	return mc.filterHistory(func(change *model.TagChange) bool {
+		return equalTags(change.TagNumber, &tagNumber) || equalTags(change.PreviousTagNumber, &tagNumber)
+	}), nil
+}
+
+// GetTagStandings reconstructs who held each tag at asOf from the recorded changes, ordered by tag
+func (mc *MemoryClient) GetTagStandings(ctx context.Context, asOf time.Time) ([]*model.TagStanding, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	latest := make(map[string]*model.TagChange)
+	for _, change := range mc.history {
+		if !change.ChangedAt.After(asOf) {
+			latest[change.DiscordID] = change
+		}
+	}
+
+	standings := []*model.TagStanding{}
+	for _, change := range latest {
+		if change.TagNumber != nil {
+			standings = append(standings, &model.TagStanding{TagNumber: *change.TagNumber, DiscordID: change.DiscordID, HeldSince: change.ChangedAt})
+		}
+	}
+	sort.Slice(standings, func(i, j int) bool { return standings[i].TagNumber < standings[j].TagNumber })
+	return standings, nil
+}
+
+// ListTagHolders returns up to limit active, approved users holding a tag above afterTag, ordered by tag
+func (mc *MemoryClient) ListTagHolders(ctx context.Context, afterTag, limit int) ([]*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	users := []*model.User{}
+	for _, user := range mc.users {
+		if user.TagNumber != nil && *user.TagNumber > afterTag && approvedMember(user) {
+			users = append(users, copyUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return *users[i].TagNumber < *users[j].TagNumber })
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// SearchUsers returns up to limit active, approved users, ranked by nameScore in place of the
+// trigram similarity PostgreSQL uses
+func (mc *MemoryClient) SearchUsers(ctx context.Context, query string, limit int) ([]*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	users := []*model.User{}
+	for _, user := range mc.users {
+		if approvedMember(user) {
+			users = append(users, copyUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := nameScore(query, users[i].Name), nameScore(query, users[j].Name)
+		if a != b {
+			return a > b
+		}
+		return users[i].DiscordID < users[j].DiscordID
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// ListUsers returns a page of active users matching opts
+func (mc *MemoryClient) ListUsers(ctx context.Context, opts ListUsersOptions) ([]*model.User, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	users := make([]*model.User, 0, len(mc.users))
+	for _, user := range mc.users {
+		users = append(users, copyUser(user))
+	}
+	return opts.Apply(users), nil
+}
+
+// CreateAPIKey stores a new API key under the hash of its secret and sets its CreatedAt
+func (mc *MemoryClient) CreateAPIKey(ctx context.Context, key *model.APIKey, hash []byte) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if _, ok := mc.keyHashes[string(hash)]; ok {
+		return AlreadyExistsf("API key already exists")
+	}
+	key.CreatedAt = time.Now()
+	mc.keyHashes[string(hash)] = key.ID
+	mc.apiKeys = append(mc.apiKeys, copyAPIKey(key))
+	return nil
+}
+
+// GetAPIKeyByHash retrieves the unrevoked API key whose secret hashes to hash
+func (mc *MemoryClient) GetAPIKeyByHash(ctx context.Context, hash []byte) (*model.APIKey, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	key := mc.apiKey(mc.keyHashes[string(hash)])
+	if key == nil || key.RevokedAt != nil {
+		return nil, NotFoundf("API key not found")
+	}
+	return copyAPIKey(key), nil
+}
+
+// ListAPIKeys returns the API keys oldest first, leaving out revoked keys unless includeRevoked is set
+func (mc *MemoryClient) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	keys := []*model.APIKey{}
+	for _, key := range mc.apiKeys {
+		if includeRevoked || key.RevokedAt == nil {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an unrevoked API key
+func (mc *MemoryClient) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	key := mc.apiKey(id)
+	if key == nil || key.RevokedAt != nil {
+		return nil, NotFoundf("active API key %s not found", id)
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return copyAPIKey(key), nil
+}
+
+// apiKey returns the stored API key with the given ID, or nil
+func (mc *MemoryClient) apiKey(id string) *model.APIKey {
+	for _, key := range mc.apiKeys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// copyAPIKey returns a deep copy so callers never share state with the store
+func copyAPIKey(key *model.APIKey) *model.APIKey {
+	c := *key
+	c.Scopes = append([]model.APIKeyScope(nil), key.Scopes...)
+	return &c
+}
+
+// filterHistory returns copies of the recorded tag changes matching keep, in recording order
+func (mc *MemoryClient) filterHistory(keep func(*model.TagChange) bool) []*model.TagChange {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	history := []*model.TagChange{}
+	for _, change := range mc.history {
+		if keep(change) {
+			c := *change
+			history = append(history, &c)
+		}
+	}
+	return history
+}
+
+// recordTagChange appends to the tag history unless the tag did not actually change.
+// The caller must hold mc.mu for writing.
+func (mc *MemoryClient) recordTagChange(ctx context.Context, discordID string, previous, current *int, reason model.TagChangeReason) {
+	if equalTags(previous, current) {
+		return
+	}
+	mc.history = append(mc.history, &model.TagChange{
+		DiscordID:         discordID,
+		PreviousTagNumber: copyInt(previous),
+		TagNumber:         copyInt(current),
+		Reason:            reason,
+		ActorDiscordID:    actorDiscordID(ctx),
+		ChangedAt:         time.Now(),
+	})
+	mc.recordEvent(EventTagChanged, discordID, TagChangedEvent{
+		DiscordID: discordID, PreviousTagNumber: copyInt(previous), TagNumber: copyInt(current), Reason: reason, ActorDiscordID: actorDiscordID(ctx),
+	})
+}
+
+// recordEvent appends a domain event to the outbox. The caller must hold mc.mu for writing.
+func (mc *MemoryClient) recordEvent(eventType EventType, discordID string, payload any) {
+	data, _ := json.Marshal(payload) // Event payloads hold only plain, encodable fields
+	mc.lastEvent++
+	mc.outbox = append(mc.outbox, Event{
+		ID:         mc.lastEvent,
+		Type:       eventType,
+		DiscordID:  discordID,
+		Payload:    data,
+		OccurredAt: time.Now(),
+	})
+}
+
+// RelayEvents passes up to limit unpublished events to publish in outbox order, stopping at the
+// first one publish rejects, and returns how many were published. Published events are dropped
+// from the outbox.
+func (mc *MemoryClient) RelayEvents(ctx context.Context, limit int, publish func(context.Context, Event) error) (int, error) {
+	mc.relayMu.Lock()
+	defer mc.relayMu.Unlock()
+
+	mc.mu.RLock()
+	pending := append([]Event(nil), mc.outbox[:min(limit, len(mc.outbox))]...)
+	mc.mu.RUnlock()
+
+	published := 0
+	var publishErr error
+	for _, event := range pending {
+		if publishErr = publish(ctx, event); publishErr != nil {
+			break
+		}
+		published++
+	}
+
+	// Events are only appended while publishing, and relayMu keeps other relays out, so the
+	// published events are still at the front
+	mc.mu.Lock()
+	mc.outbox = slices.Delete(mc.outbox, 0, published)
+	mc.mu.Unlock()
+	if publishErr != nil {
+		return published, fmt.Errorf("failed to publish event: %w", publishErr)
+	}
+	return published, nil
+}
+
+// approvedMember reports whether a stored user is active and approved, and so listed as a tag holder
+func approvedMember(user *model.User) bool {
+	return !user.Deleted && user.Status == model.ApprovalStatusApproved
+}
+
+// tagHolder returns the stored user holding tagNumber, including soft-deleted users
+// to match the unique index in PostgreSQL. The caller must hold mc.mu.
+func (mc *MemoryClient) tagHolder(tagNumber int) *model.User {
+	for _, user := range mc.users {
+		if user.TagNumber != nil && *user.TagNumber == tagNumber {
+			return user
+		}
+	}
+	return nil
+}
+
+// Close is a no-op for the in-memory store
+func (mc *MemoryClient) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,776 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+)
+
+// Compile-time check that MemoryClient satisfies PGClient
+var _ service.PGClient = (*service.MemoryClient)(nil)
+
+func TestMemoryClient_CreateUser_Unique(t *testing.T) {
+	client := service.NewMemoryClient()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := client.CreateUser(ctx, &model.User{DiscordID: "12345", Name: "Test User", Role: service.DefaultRole})
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			} else if service.ErrorCodeOf(err) != service.CodeAlreadyExists {
+				t.Errorf("CreateUser() duplicate error = %v, want ALREADY_EXISTS", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("CreateUser() succeeded %d times, want 1", created)
+	}
+}
+
+func TestMemoryClient_ReturnsCopies(t *testing.T) {
+	client := service.NewMemoryClient()
+	ctx := context.Background()
+
+	tag := 4
+	user := &model.User{DiscordID: "12345", Name: "Test User", TagNumber: &tag, Role: service.DefaultRole}
+	if err := client.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	tag = 99
+	user.Name = "Changed"
+
+	got, err := client.GetUserByDiscordID(ctx, "12345", false)
+	if err != nil {
+		t.Fatalf("GetUserByDiscordID() error = %v", err)
+	}
+	if got.Name != "Test User" || *got.TagNumber != 4 {
+		t.Errorf("GetUserByDiscordID() = %+v, store was mutated through caller's pointer", got)
+	}
+}
+
+func TestUserServiceImpl_MemoryClient_Lifecycle(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := context.Background()
+
+	if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: "12345", Name: "Test User"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	name := "Renamed"
+	if _, err := userService.UpdateUser(ctx, "12345", model.UpdateUserInput{Name: graphql.OmittableOf(&name)}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	if _, err := userService.DeleteUser(ctx, "12345"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := userService.GetUserByDiscordID(ctx, "12345", false); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetUserByDiscordID() deleted user error = %v, want ErrNotFound", err)
+	}
+	if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: "12345", Name: "Test User"}); service.ErrorCodeOf(err) != service.CodeAlreadyExists {
+		t.Errorf("CreateUser() deleted user error = %v, want ALREADY_EXISTS", err)
+	}
+
+	restored, err := userService.RestoreUser(ctx, "12345")
+	if err != nil {
+		t.Fatalf("RestoreUser() error = %v", err)
+	}
+	if restored.Name != name || restored.Deleted {
+		t.Errorf("RestoreUser() = %+v, want active user named %q", restored, name)
+	}
+}
+
+func TestUserServiceImpl_ClaimTag(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor}) // Users added by an Editor are approved
+
+	for _, id := range []string{"alice", "bob"} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+
+	if _, err := userService.ClaimTag(ctx, "alice", 1); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 1); service.ErrorCodeOf(err) != service.CodeAlreadyExists {
+		t.Errorf("ClaimTag() taken tag error = %v, want ALREADY_EXISTS", err)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 0); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("ClaimTag() zero tag error = %v, want INVALID_INPUT", err)
+	}
+
+	// Claiming a new tag releases the old one
+	if _, err := userService.ClaimTag(ctx, "alice", 2); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.GetUserByTagNumber(ctx, 1); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetUserByTagNumber() released tag error = %v, want ErrNotFound", err)
+	}
+	holder, err := userService.GetUserByTagNumber(ctx, 2)
+	if err != nil || holder.DiscordID != "alice" {
+		t.Errorf("GetUserByTagNumber() = %v, %v, want alice", holder, err)
+	}
+}
+
+func TestUserServiceImpl_DeleteUser_ReleasesTag(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	for _, id := range []string{"alice", "bob"} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+	if _, err := userService.ClaimTag(ctx, "alice", 1); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+
+	deleted, err := userService.DeleteUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if deleted.TagNumber != nil {
+		t.Errorf("DeleteUser() TagNumber = %d, want nil", *deleted.TagNumber)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 1); err != nil {
+		t.Errorf("ClaimTag() tag of a deleted user error = %v", err)
+	}
+
+	history, err := userService.GetTagHistoryByUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetTagHistoryByUser() error = %v", err)
+	}
+	if len(history) != 2 || history[1].Reason != model.TagChangeReasonRelease {
+		t.Errorf("GetTagHistoryByUser() = %v, want a claim then a release", history)
+	}
+
+	// Restoring the user does not hand the tag back
+	restored, err := userService.RestoreUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("RestoreUser() error = %v", err)
+	}
+	if restored.TagNumber != nil {
+		t.Errorf("RestoreUser() TagNumber = %d, want nil", *restored.TagNumber)
+	}
+}
+
+func TestUserServiceImpl_SwapTags(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor}) // Only approved users may hold tags
+
+	for _, id := range []string{"alice", "bob", "carol"} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+	if _, err := userService.ClaimTag(ctx, "alice", 1); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 2); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+
+	swap, err := userService.SwapTags(ctx, "alice", "bob")
+	if err != nil {
+		t.Fatalf("SwapTags() error = %v", err)
+	}
+	if *swap.UserA.TagNumber != 2 || *swap.UserB.TagNumber != 1 {
+		t.Errorf("SwapTags() tags = %d, %d, want 2, 1", *swap.UserA.TagNumber, *swap.UserB.TagNumber)
+	}
+
+	if _, err := userService.SwapTags(ctx, "alice", "carol"); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("SwapTags() untagged user error = %v, want INVALID_INPUT", err)
+	}
+	if _, err := userService.SwapTags(ctx, "alice", "alice"); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("SwapTags() same user error = %v, want INVALID_INPUT", err)
+	}
+}
+
+func TestUserServiceImpl_ReassignTags(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor}) // Users added by an Editor are approved
+
+	for id, tag := range map[string]int{"alice": 5, "bob": 3, "carol": 1, "dave": 2} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+		if _, err := userService.ClaimTag(ctx, id, tag); err != nil {
+			t.Fatalf("ClaimTag(%s) error = %v", id, err)
+		}
+	}
+
+	results := []*model.RoundResultInput{{DiscordID: "alice"}, {DiscordID: "bob"}, {DiscordID: "carol"}}
+	assignments, err := userService.ReassignTags(ctx, results)
+	if err != nil {
+		t.Fatalf("ReassignTags() error = %v", err)
+	}
+
+	want := []struct {
+		discordID     string
+		before, after int
+	}{{"alice", 5, 1}, {"bob", 3, 3}, {"carol", 1, 5}}
+	for i, w := range want {
+		got := assignments[i]
+		if got.User.DiscordID != w.discordID || got.PreviousTagNumber != w.before || got.TagNumber != w.after || *got.User.TagNumber != w.after {
+			t.Errorf("ReassignTags()[%d] = %s %d -> %d, want %s %d -> %d",
+				i, got.User.DiscordID, got.PreviousTagNumber, got.TagNumber, w.discordID, w.before, w.after)
+		}
+	}
+
+	// Non-participants keep their tags
+	if dave, _ := userService.GetUserByTagNumber(ctx, 2); dave == nil || dave.DiscordID != "dave" {
+		t.Errorf("GetUserByTagNumber(2) = %v, want dave", dave)
+	}
+
+	duplicate := []*model.RoundResultInput{{DiscordID: "alice"}, {DiscordID: "alice"}}
+	if _, err := userService.ReassignTags(ctx, duplicate); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("ReassignTags() duplicate error = %v, want INVALID_INPUT", err)
+	}
+}
+
+func TestUserServiceImpl_TagHistory(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := context.Background()
+	adminCtx := service.WithCaller(ctx, &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+
+	for _, id := range []string{"alice", "bob"} {
+		if _, err := userService.CreateUser(adminCtx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+	if _, err := userService.ClaimTag(ctx, "alice", 1); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 2); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.SwapTags(adminCtx, "alice", "bob"); err != nil {
+		t.Fatalf("SwapTags() error = %v", err)
+	}
+	if _, err := userService.UpdateUser(ctx, "alice", model.UpdateUserInput{TagNumber: graphql.OmittableOf[*int](nil)}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	history, err := userService.GetTagHistoryByUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetTagHistoryByUser() error = %v", err)
+	}
+	wantReasons := []model.TagChangeReason{model.TagChangeReasonClaim, model.TagChangeReasonSwap, model.TagChangeReasonRelease}
+	if len(history) != len(wantReasons) {
+		t.Fatalf("GetTagHistoryByUser() returned %d changes, want %d", len(history), len(wantReasons))
+	}
+	for i, reason := range wantReasons {
+		if history[i].Reason != reason {
+			t.Errorf("GetTagHistoryByUser()[%d].Reason = %s, want %s", i, history[i].Reason, reason)
+		}
+	}
+	if actor := history[1].ActorDiscordID; actor == nil || *actor != "admin" {
+		t.Errorf("GetTagHistoryByUser()[1].ActorDiscordID = %v, want admin", actor)
+	}
+	if history[2].TagNumber != nil {
+		t.Errorf("GetTagHistoryByUser()[2].TagNumber = %d, want nil", *history[2].TagNumber)
+	}
+
+	// Tag 1 went to alice, then to bob in the swap
+	byTag, err := userService.GetTagHistoryByTag(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetTagHistoryByTag() error = %v", err)
+	}
+	if len(byTag) != 3 {
+		t.Errorf("GetTagHistoryByTag(1) returned %d changes, want 3", len(byTag))
+	}
+	if _, err := userService.GetTagHistoryByTag(ctx, 0); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("GetTagHistoryByTag() zero tag error = %v, want INVALID_INPUT", err)
+	}
+}
+
+func TestUserServiceImpl_GetTagStandings(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor}) // Only approved users may hold tags
+
+	for _, id := range []string{"alice", "bob"} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+	beforeClaims := time.Now()
+	if _, err := userService.ClaimTag(ctx, "alice", 1); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	if _, err := userService.ClaimTag(ctx, "bob", 2); err != nil {
+		t.Fatalf("ClaimTag() error = %v", err)
+	}
+	beforeSwap := time.Now()
+	if _, err := userService.SwapTags(ctx, "alice", "bob"); err != nil {
+		t.Fatalf("SwapTags() error = %v", err)
+	}
+
+	holders := func(standings []*model.TagStanding) map[int]string {
+		m := make(map[int]string, len(standings))
+		for _, s := range standings {
+			m[s.TagNumber] = s.DiscordID
+		}
+		return m
+	}
+	tests := []struct {
+		name string
+		asOf *time.Time
+		want map[int]string
+	}{
+		{name: "Before_Claims", asOf: &beforeClaims, want: map[int]string{}},
+		{name: "Before_Swap", asOf: &beforeSwap, want: map[int]string{1: "alice", 2: "bob"}},
+		{name: "Now", want: map[int]string{1: "bob", 2: "alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings, err := userService.GetTagStandings(ctx, tt.asOf)
+			if err != nil {
+				t.Fatalf("GetTagStandings() error = %v", err)
+			}
+			if got := holders(standings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTagStandings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServiceImpl_GetTagLeaderboard(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor}) // Users added by an Editor are approved
+
+	for id, tag := range map[string]int{"alice": 4, "bob": 1, "carol": 7, "dave": 2, "erin": 9} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: id}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+		if _, err := userService.ClaimTag(ctx, id, tag); err != nil {
+			t.Fatalf("ClaimTag(%s) error = %v", id, err)
+		}
+	}
+	if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: "frank", Name: "frank"}); err != nil {
+		t.Fatalf("CreateUser(frank) error = %v", err)
+	}
+	if _, err := userService.DeleteUser(ctx, "carol"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+
+	// Untagged and deleted users are left out
+	want := [][]string{{"bob", "dave"}, {"alice", "erin"}}
+	var after *string
+	for page, ids := range want {
+		connection, err := userService.GetTagLeaderboard(ctx, 2, after)
+		if err != nil {
+			t.Fatalf("GetTagLeaderboard() page %d error = %v", page, err)
+		}
+		var got []string
+		for _, edge := range connection.Edges {
+			got = append(got, edge.Node.DiscordID)
+		}
+		if !reflect.DeepEqual(got, ids) {
+			t.Errorf("GetTagLeaderboard() page %d = %v, want %v", page, got, ids)
+		}
+		if hasNext := page < len(want)-1; connection.PageInfo.HasNextPage != hasNext {
+			t.Errorf("GetTagLeaderboard() page %d HasNextPage = %v, want %v", page, connection.PageInfo.HasNextPage, hasNext)
+		}
+		after = connection.PageInfo.EndCursor
+	}
+
+	last, err := userService.GetTagLeaderboard(ctx, 2, after)
+	if err != nil || len(last.Edges) != 0 || last.PageInfo.EndCursor != nil {
+		t.Errorf("GetTagLeaderboard() past the end = %v, %v, want an empty page", last, err)
+	}
+
+	invalid := "not-a-cursor"
+	if _, err := userService.GetTagLeaderboard(ctx, 2, &invalid); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("GetTagLeaderboard() invalid cursor error = %v, want INVALID_INPUT", err)
+	}
+	for _, first := range []int{0, 101} {
+		if _, err := userService.GetTagLeaderboard(ctx, first, nil); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+			t.Errorf("GetTagLeaderboard(first = %d) error = %v, want INVALID_INPUT", first, err)
+		}
+	}
+}
+
+func TestUserServiceImpl_ListUsers(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+
+	editor, one := service.RoleEditor, 1
+	inputs := []model.UserInput{
+		{DiscordID: "1", Name: "carol", Role: graphql.OmittableOf(&editor)},
+		{DiscordID: "2", Name: "alice"},
+		{DiscordID: "3", Name: "bob", TagNumber: graphql.OmittableOf(&one)},
+		{DiscordID: "4", Name: "alex", Role: graphql.OmittableOf(&editor)},
+		{DiscordID: "5", Name: "dave"},
+	}
+	var midway time.Time
+	for i, input := range inputs {
+		if i == 2 {
+			midway = time.Now()
+		}
+		if _, err := userService.CreateUser(ctx, input); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", input.DiscordID, err)
+		}
+	}
+	if _, err := userService.DeleteUser(ctx, "5"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+
+	hasTag, prefix := false, "AL"
+	tests := []struct {
+		name    string
+		filter  *model.UserFilter
+		orderBy *model.UserOrder
+		want    []string
+	}{
+		{name: "Default_Order", want: []string{"4", "2", "3", "1"}},
+		{name: "Role", filter: &model.UserFilter{Role: graphql.OmittableOf(&editor)}, want: []string{"4", "1"}},
+		{name: "No_Tag", filter: &model.UserFilter{HasTag: graphql.OmittableOf(&hasTag)}, want: []string{"4", "2", "1"}},
+		{name: "Name_Prefix", filter: &model.UserFilter{NamePrefix: graphql.OmittableOf(&prefix)}, want: []string{"4", "2"}},
+		{name: "Created_After", filter: &model.UserFilter{CreatedAfter: graphql.OmittableOf(&midway)}, want: []string{"4", "3"}},
+		{name: "Created_Before", filter: &model.UserFilter{CreatedBefore: graphql.OmittableOf(&midway)}, want: []string{"2", "1"}},
+		{
+			name:    "Created_Desc",
+			orderBy: &model.UserOrder{Field: model.UserOrderFieldCreatedAt, Direction: model.OrderDirectionDesc},
+			want:    []string{"4", "3", "2", "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Page two at a time to exercise the cursor for every ordering
+			var got []string
+			var after *string
+			for {
+				connection, err := userService.ListUsers(ctx, tt.filter, tt.orderBy, 2, after)
+				if err != nil {
+					t.Fatalf("ListUsers() error = %v", err)
+				}
+				for _, edge := range connection.Edges {
+					got = append(got, edge.Node.DiscordID)
+				}
+				if !connection.PageInfo.HasNextPage {
+					break
+				}
+				after = connection.PageInfo.EndCursor
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListUsers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	invalidRole := model.Role("Owner")
+	if _, err := userService.ListUsers(ctx, &model.UserFilter{Role: graphql.OmittableOf(&invalidRole)}, nil, 2, nil); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("ListUsers() invalid role error = %v, want INVALID_INPUT", err)
+	}
+	tagCursor := "dGFnOjE" // A tagLeaderboard cursor
+	if _, err := userService.ListUsers(ctx, nil, nil, 2, &tagCursor); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("ListUsers() foreign cursor error = %v, want INVALID_INPUT", err)
+	}
+}
+
+func TestUserServiceImpl_SearchUsers(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	ctx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	for id, name := range map[string]string{"1": "Jonathan Smith", "2": "Jon", "3": "Joan", "4": "Alice", "5": "Jon Deleted"} {
+		if _, err := userService.CreateUser(ctx, model.UserInput{DiscordID: id, Name: name}); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+	}
+	if _, err := userService.DeleteUser(ctx, "5"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	// Users still awaiting approval are left out of results
+	selfCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "6"})
+	if _, err := userService.CreateUser(selfCtx, model.UserInput{DiscordID: "6", Name: "Jon Pending"}); err != nil {
+		t.Fatalf("CreateUser(6) error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "Exact_First", query: "JON", limit: 10, want: []string{"Jon", "Jonathan Smith", "Joan"}},
+		{name: "Limit", query: "jon", limit: 2, want: []string{"Jon", "Jonathan Smith"}},
+		{name: "Misspelled_Surname", query: "smtih", limit: 10, want: []string{"Jonathan Smith"}},
+		{name: "No_Match", query: "zzz", limit: 10, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := userService.SearchUsers(ctx, tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("SearchUsers() error = %v", err)
+			}
+			var got []string
+			for i, result := range results {
+				got = append(got, result.User.Name)
+				if result.Score <= 0 || result.Score > 1 || (i > 0 && result.Score > results[i-1].Score) {
+					t.Errorf("SearchUsers()[%d].Score = %v, want descending scores in (0, 1]", i, result.Score)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchUsers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if results, _ := userService.SearchUsers(ctx, "Jon", 1); len(results) != 1 || results[0].Score != 1 {
+		t.Errorf("SearchUsers() exact match = %v, want score 1", results)
+	}
+	if _, err := userService.SearchUsers(ctx, "  ", 10); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("SearchUsers() blank query error = %v, want INVALID_INPUT", err)
+	}
+	if _, err := userService.SearchUsers(ctx, "jon", 26); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("SearchUsers() limit error = %v, want INVALID_INPUT", err)
+	}
+}
+
+func TestUserServiceImpl_UpdateUserRole(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	admin, editor := service.RoleAdmin, service.RoleEditor
+	inputs := []model.UserInput{
+		{DiscordID: "admin", Name: "admin", Role: graphql.OmittableOf(&admin)},
+		{DiscordID: "editor", Name: "editor", Role: graphql.OmittableOf(&editor)},
+		{DiscordID: "player", Name: "player"},
+	}
+	for _, input := range inputs {
+		if _, err := userService.CreateUser(adminCtx, input); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", input.DiscordID, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		discordID string
+		role      model.Role
+		wantCode  service.ErrorCode
+	}{
+		{"Editor_Promotes_To_Editor", editorCtx, "player", service.RoleEditor, ""},
+		{"Editor_Cannot_Grant_Admin", editorCtx, "player", service.RoleAdmin, service.CodeForbidden},
+		{"Editor_Cannot_Demote_Admin", editorCtx, "admin", service.RoleRattler, service.CodeForbidden},
+		{"Admin_Grants_Admin", adminCtx, "player", service.RoleAdmin, ""},
+		{"Anonymous", context.Background(), "player", service.RoleRattler, service.CodeForbidden},
+		{"Invalid_Role", adminCtx, "player", "Owner", service.CodeInvalidInput},
+		{"Unknown_User", adminCtx, "ghost", service.RoleEditor, service.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userService.UpdateUserRole(tt.ctx, tt.discordID, tt.role)
+			if code := service.ErrorCodeOf(err); tt.wantCode != "" && code != tt.wantCode {
+				t.Fatalf("UpdateUserRole() error = %v, want %v", err, tt.wantCode)
+			}
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("UpdateUserRole() error = %v", err)
+				}
+				if got.Role != tt.role {
+					t.Errorf("UpdateUserRole() role = %s, want %s", got.Role, tt.role)
+				}
+			}
+		})
+	}
+}
+
+func TestUserServiceImpl_ManageHigherRole(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "admin", Role: service.RoleAdmin})
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	admin, editor := service.RoleAdmin, service.RoleEditor
+	inputs := []model.UserInput{
+		{DiscordID: "admin", Name: "admin", Role: graphql.OmittableOf(&admin)},
+		{DiscordID: "editor", Name: "editor", Role: graphql.OmittableOf(&editor)},
+		{DiscordID: "player", Name: "player"},
+	}
+	for _, input := range inputs {
+		if _, err := userService.CreateUser(adminCtx, input); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", input.DiscordID, err)
+		}
+	}
+	name := "renamed"
+	rename := model.UpdateUserInput{Name: graphql.OmittableOf(&name)}
+
+	// An Editor may not edit or delete an Admin, who could then not be restored
+	if _, err := userService.UpdateUser(editorCtx, "admin", rename); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("UpdateUser() Editor on Admin error = %v, want FORBIDDEN", err)
+	}
+	if _, err := userService.DeleteUser(editorCtx, "admin"); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("DeleteUser() Editor on Admin error = %v, want FORBIDDEN", err)
+	}
+	if _, err := userService.GetUserByDiscordID(adminCtx, "admin", false); err != nil {
+		t.Errorf("GetUserByDiscordID() Admin after refused delete error = %v", err)
+	}
+
+	if _, err := userService.UpdateUser(editorCtx, "editor", rename); err != nil {
+		t.Errorf("UpdateUser() Editor on self error = %v", err)
+	}
+	if _, err := userService.UpdateUser(editorCtx, "player", rename); err != nil {
+		t.Errorf("UpdateUser() Editor on Rattler error = %v", err)
+	}
+	if _, err := userService.DeleteUser(adminCtx, "editor"); err != nil {
+		t.Errorf("DeleteUser() Admin on Editor error = %v", err)
+	}
+	if _, err := userService.DeleteUser(editorCtx, "ghost"); service.ErrorCodeOf(err) != service.CodeNotFound {
+		t.Errorf("DeleteUser() unknown user error = %v, want NOT_FOUND", err)
+	}
+}
+
+func TestUserServiceImpl_ApprovalQueue(t *testing.T) {
+	userService := service.NewUserService(service.NewMemoryClient())
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	for _, id := range []string{"alice", "bob"} {
+		selfCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: id})
+		user, err := userService.CreateUser(selfCtx, model.UserInput{DiscordID: id, Name: id})
+		if err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", id, err)
+		}
+		if user.Status != model.ApprovalStatusPending {
+			t.Errorf("CreateUser(%s) status = %s, want PENDING", id, user.Status)
+		}
+	}
+	added, err := userService.CreateUser(editorCtx, model.UserInput{DiscordID: "carol", Name: "carol"})
+	if err != nil {
+		t.Fatalf("CreateUser(carol) error = %v", err)
+	}
+	if added.Status != model.ApprovalStatusApproved {
+		t.Errorf("CreateUser(carol) by an Editor status = %s, want APPROVED", added.Status)
+	}
+
+	pending := model.ApprovalStatusPending
+	filter := &model.UserFilter{Status: graphql.OmittableOf(&pending)}
+	page, err := userService.ListUsers(editorCtx, filter, nil, 10, nil)
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(page.Edges) != 2 {
+		t.Errorf("ListUsers(PENDING) returned %d users, want 2", len(page.Edges))
+	}
+
+	playerCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "carol", Role: service.RoleRattler})
+	if _, err := userService.ApproveUser(playerCtx, "alice"); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("ApproveUser() by a player error = %v, want FORBIDDEN", err)
+	}
+
+	approved, err := userService.ApproveUser(editorCtx, "alice")
+	if err != nil {
+		t.Fatalf("ApproveUser() error = %v", err)
+	}
+	if approved.Status != model.ApprovalStatusApproved || approved.ReviewedBy == nil || *approved.ReviewedBy != "editor" || approved.ReviewedAt == nil {
+		t.Errorf("ApproveUser() = %+v, want approved by editor", approved)
+	}
+	if _, err := userService.RejectUser(editorCtx, "alice", "too late"); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("RejectUser() approved user error = %v, want INVALID_INPUT", err)
+	}
+
+	if _, err := userService.RejectUser(editorCtx, "bob", " "); service.ErrorCodeOf(err) != service.CodeInvalidInput {
+		t.Errorf("RejectUser() without reason error = %v, want INVALID_INPUT", err)
+	}
+	rejected, err := userService.RejectUser(editorCtx, "bob", "Not a club member")
+	if err != nil {
+		t.Fatalf("RejectUser() error = %v", err)
+	}
+	if rejected.Status != model.ApprovalStatusRejected || rejected.RejectionReason == nil || *rejected.RejectionReason != "Not a club member" {
+		t.Errorf("RejectUser() = %+v, want rejected with reason", rejected)
+	}
+
+	page, err = userService.ListUsers(editorCtx, filter, nil, 10, nil)
+	if err != nil || len(page.Edges) != 0 {
+		t.Errorf("ListUsers(PENDING) after review = %v, %v, want no users", page, err)
+	}
+}
+
+func TestUserServiceImpl_PendingTagHolders(t *testing.T) {
+	client := service.NewMemoryClient()
+	userService := service.NewUserService(client)
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editor", Role: service.RoleEditor})
+
+	selfCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "alice"})
+	tag := 1
+	if _, err := userService.CreateUser(selfCtx, model.UserInput{DiscordID: "alice", Name: "Alice", TagNumber: graphql.OmittableOf(&tag)}); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("CreateUser() pending with a tag error = %v, want FORBIDDEN", err)
+	}
+	if _, err := userService.CreateUser(selfCtx, model.UserInput{DiscordID: "alice", Name: "Alice"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := userService.CreateUser(editorCtx, model.UserInput{DiscordID: "bob", Name: "Bob", TagNumber: graphql.OmittableOf(&tag)}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// Tags cannot be handed to a pending user, whom tag lookups would not find
+	if _, err := userService.ClaimTag(editorCtx, "alice", 5); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("ClaimTag() pending user error = %v, want FORBIDDEN", err)
+	}
+	five := 5
+	if _, err := userService.UpdateUser(editorCtx, "alice", model.UpdateUserInput{TagNumber: graphql.OmittableOf(&five)}); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("UpdateUser() tag for pending user error = %v, want FORBIDDEN", err)
+	}
+
+	// A pending user holding a tag from before approvals were checked stays out of tag lookups and
+	// cannot trade it
+	carolTag := 3
+	if err := client.CreateUser(editorCtx, &model.User{DiscordID: "carol", Name: "Carol", TagNumber: &carolTag, Role: service.RoleRattler, Status: model.ApprovalStatusPending}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := userService.GetUserByTagNumber(editorCtx, carolTag); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetUserByTagNumber() pending holder error = %v, want ErrNotFound", err)
+	}
+	page, err := userService.GetTagLeaderboard(editorCtx, 10, nil)
+	if err != nil || len(page.Edges) != 1 {
+		t.Errorf("GetTagLeaderboard() with a pending holder = %v, %v, want only bob", page, err)
+	}
+	if _, err := userService.SwapTags(editorCtx, "bob", "carol"); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("SwapTags() with a pending user error = %v, want FORBIDDEN", err)
+	}
+	results := []*model.RoundResultInput{{DiscordID: "carol"}, {DiscordID: "bob"}}
+	if _, err := userService.ReassignTags(editorCtx, results); service.ErrorCodeOf(err) != service.CodeForbidden {
+		t.Errorf("ReassignTags() with a pending user error = %v, want FORBIDDEN", err)
+	}
+
+	// Rejecting the user releases their tag for others to claim
+	rejected, err := userService.RejectUser(editorCtx, "carol", "Not a club member")
+	if err != nil {
+		t.Fatalf("RejectUser() error = %v", err)
+	}
+	if rejected.TagNumber != nil {
+		t.Errorf("RejectUser() TagNumber = %d, want nil", *rejected.TagNumber)
+	}
+	if _, err := userService.ClaimTag(editorCtx, "bob", carolTag); err != nil {
+		t.Errorf("ClaimTag() tag of a rejected user error = %v", err)
+	}
+
+	if _, err := userService.ApproveUser(editorCtx, "alice"); err != nil {
+		t.Fatalf("ApproveUser() error = %v", err)
+	}
+	if user, err := userService.ClaimTag(editorCtx, "alice", 5); err != nil || *user.TagNumber != 5 {
+		t.Errorf("ClaimTag() approved user = %v, %v, want tag 5", user, err)
+	}
+}
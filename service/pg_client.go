@@ -4,26 +4,84 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// PGClient interface defines methods for database operations
+// PGClient interface defines methods for database operations.
+// Lookups and updates of a missing user return an error matching ErrNotFound, never a nil user,
+// except batch lookups, which return nil in place of each missing user.
 type PGClient interface {
 	CreateUser(ctx context.Context, user *model.User) error
-	GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error)
+	GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error)
+	GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error)
+	UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error)
+	DeleteUser(ctx context.Context, discordID string) (*model.User, error)
+	RestoreUser(ctx context.Context, discordID string) (*model.User, error)
+	UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error)
+	ReviewUser(ctx context.Context, discordID string, review UserReview) (*model.User, error)
+	GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error)
+	GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error)
+	ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error)
+	SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.User, *model.User, error)
+	ReassignTags(ctx context.Context, discordIDs []string) ([]*model.TagAssignment, error)
+	GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error)
+	GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error)
+	GetTagStandings(ctx context.Context, asOf time.Time) ([]*model.TagStanding, error)
+	ListTagHolders(ctx context.Context, afterTag, limit int) ([]*model.User, error)
+	ListUsers(ctx context.Context, opts ListUsersOptions) ([]*model.User, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]*model.User, error)
+	CreateAPIKey(ctx context.Context, key *model.APIKey, hash []byte) error
+	GetAPIKeyByHash(ctx context.Context, hash []byte) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error)
+	RelayEvents(ctx context.Context, limit int, publish func(context.Context, Event) error) (int, error)
 	Close(ctx context.Context) error
 }
 
+// DB is the subset of *pgxpool.Pool used by PGClientImpl, so tests can swap in pgxmock
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Close()
+}
+
 // PGClientImpl is the implementation of the PGClient interface
 type PGClientImpl struct {
-	Pool *pgxpool.Pool
+	Pool DB
 }
 
+// tagNumberConstraint is the unique index that keeps bag tags unique
+const tagNumberConstraint = "users_tag_number_key"
+
+// userColumns lists the users columns in the order scanUser expects them
+const userColumns = "discord_id, name, tag_number, role, deleted_at IS NOT NULL, created_at, status, rejection_reason, reviewed_by, reviewed_at"
+
+// tagChangeColumns lists the tag_history columns in the order model.TagChange is scanned
+const tagChangeColumns = "discord_id, previous_tag_number, tag_number, reason, actor_discord_id, changed_at"
+
+// qualifiedUserColumns is userColumns prefixed with the users table, for statements joining other relations
+const qualifiedUserColumns = "users.discord_id, users.name, users.tag_number, users.role, users.deleted_at IS NOT NULL, users.created_at, " +
+	"users.status, users.rejection_reason, users.reviewed_by, users.reviewed_at"
+
+// relayLockKey identifies the advisory lock held while relaying events, so concurrent relays
+// cannot publish the outbox out of order
+const relayLockKey = 0x74637200 // "tcr\0"
+
+// apiKeyColumns lists the api_keys columns in the order scanAPIKey expects them
+const apiKeyColumns = "id, name, prefix, scopes, created_by, created_at, revoked_at"
+
 // NewPGClient creates a new PGClient
 func NewPGClient(dataSourceName string) (*PGClientImpl, error) {
 	config, err := pgxpool.ParseConfig(dataSourceName)
@@ -44,30 +102,878 @@ func NewPGClient(dataSourceName string) (*PGClientImpl, error) {
 	return &PGClientImpl{Pool: pool}, nil
 }
 
-// GetUser ByDiscordID retrieves a user by Discord ID
-func (pg *PGClientImpl) GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
+// scanUser reads a single row selected with userColumns into a model.User
+func scanUser(row pgx.Row) (*model.User, error) {
 	var user model.User
-	err := pg.Pool.QueryRow(ctx, "SELECT discord_id, name FROM users WHERE discord_id = $1", discordID).Scan(&user.DiscordID, &user.Name)
+	if err := row.Scan(&user.DiscordID, &user.Name, &user.TagNumber, &user.Role, &user.Deleted, &user.CreatedAt,
+		&user.Status, &user.RejectionReason, &user.ReviewedBy, &user.ReviewedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// scanAPIKey reads a single row selected with apiKeyColumns into a model.APIKey
+func scanAPIKey(row pgx.Row) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes []string
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedBy, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = make([]model.APIKeyScope, len(scopes))
+	for i, scope := range scopes {
+		key.Scopes[i] = model.APIKeyScope(scope)
+	}
+	return &key, nil
+}
+
+// GetUser ByDiscordID retrieves a user by Discord ID, skipping soft-deleted users unless includeDeleted is set
+func (pg *PGClientImpl) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	user, err := scanUser(pg.Pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND ($2 OR deleted_at IS NULL)", discordID, includeDeleted))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, nil // Return nil if user is not found
+			return nil, NotFoundf("user with Discord ID %s not found", discordID)
 		}
 		log.Printf("Error retrieving user: %v", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
-	return &user, nil
+	return user, nil
 }
 
-// CreateUser  creates a new user in PostgreSQL
+// GetUsersByDiscordIDs retrieves active users with a single ANY query. The result lines up with
+// discordIDs, holding nil for each ID with no active user.
+func (pg *PGClientImpl) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	rows, err := pg.Pool.Query(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = ANY($1) AND deleted_at IS NULL", discordIDs)
+	if err != nil {
+		log.Printf("Error retrieving users: %v", err)
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]*model.User, len(discordIDs))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		found[user.DiscordID] = user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	users := make([]*model.User, len(discordIDs))
+	for i, id := range discordIDs {
+		users[i] = found[id]
+	}
+	return users, nil
+}
+
+// CreateUser  creates a new user in PostgreSQL and sets its CreatedAt, recording any initial tag in the tag history
 func (pg *PGClientImpl) CreateUser(ctx context.Context, user *model.User) error {
-	_, err := pg.Pool.Exec(ctx, "INSERT INTO users (discord_id, name) VALUES ($1, $2)", user.DiscordID, user.Name)
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, "INSERT INTO users (discord_id, name, tag_number, role, status) VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+			user.DiscordID, user.Name, user.TagNumber, user.Role, user.Status).Scan(&user.CreatedAt)
+		if constraint, ok := uniqueViolation(err); ok {
+			if constraint == tagNumberConstraint {
+				return tagTakenError(*user.TagNumber)
+			}
+			return AlreadyExistsf("user with Discord ID %s already exists", user.DiscordID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, EventUserCreated, user.DiscordID, userEvent(ctx, user)); err != nil {
+			return err
+		}
+		return recordTagChange(ctx, tx, user.DiscordID, nil, user.TagNumber, model.TagChangeReasonClaim)
+	})
 	if err != nil {
-		log.Printf("Error creating user: %v", err)
-		return fmt.Errorf("failed to create user: %w", err)
+		return txError("create user", err)
 	}
 	return nil
 }
 
+// UpdateUser applies the fields set in input to the user and returns the updated record
+func (pg *PGClientImpl) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
+	var sets []string
+	args := []any{discordID}
+	if input.Name.IsSet() {
+		args = append(args, input.Name.Value())
+		sets = append(sets, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if input.TagNumber.IsSet() {
+		args = append(args, input.TagNumber.Value())
+		sets = append(sets, fmt.Sprintf("tag_number = $%d", len(args)))
+	}
+
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		current, err := scanUser(tx.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND deleted_at IS NULL FOR UPDATE", discordID))
+		if err == pgx.ErrNoRows {
+			return NotFoundf("user with Discord ID %s not found", discordID)
+		}
+		if err != nil || len(sets) == 0 {
+			user = current
+			return err
+		}
+		if input.TagNumber.Value() != nil {
+			if err := checkCanHoldTag(current); err != nil {
+				return err
+			}
+		}
+
+		query := "UPDATE users SET " + strings.Join(sets, ", ") + " WHERE discord_id = $1 RETURNING " + userColumns
+		user, err = scanUser(tx.QueryRow(ctx, query, args...))
+		if constraint, ok := uniqueViolation(err); ok && constraint == tagNumberConstraint {
+			return tagTakenError(*input.TagNumber.Value())
+		}
+		if err != nil {
+			return err
+		}
+		if user.Name != current.Name {
+			if err := recordEvent(ctx, tx, EventUserUpdated, discordID, userEvent(ctx, user)); err != nil {
+				return err
+			}
+		}
+		reason := model.TagChangeReasonClaim
+		if user.TagNumber == nil {
+			reason = model.TagChangeReasonRelease
+		}
+		return recordTagChange(ctx, tx, discordID, current.TagNumber, user.TagNumber, reason)
+	})
+	if err != nil {
+		return nil, txError("update user", err)
+	}
+	return user, nil
+}
+
+// DeleteUser soft-deletes a user by stamping deleted_at, releasing their tag so others can claim it
+func (pg *PGClientImpl) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		current, err := scanUser(tx.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND deleted_at IS NULL FOR UPDATE", discordID))
+		if err == pgx.ErrNoRows {
+			return NotFoundf("user with Discord ID %s not found", discordID)
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = scanUser(tx.QueryRow(ctx, "UPDATE users SET deleted_at = now(), tag_number = NULL WHERE discord_id = $1 RETURNING "+userColumns, discordID))
+		if err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, EventUserUpdated, discordID, userEvent(ctx, user)); err != nil {
+			return err
+		}
+		return recordTagChange(ctx, tx, discordID, current.TagNumber, nil, model.TagChangeReasonRelease)
+	})
+	if err != nil {
+		return nil, txError("delete user", err)
+	}
+	return user, nil
+}
+
+// RestoreUser clears deleted_at on a soft-deleted user. Their released tag is not given back.
+func (pg *PGClientImpl) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		user, err = scanUser(tx.QueryRow(ctx, "UPDATE users SET deleted_at = NULL WHERE discord_id = $1 AND deleted_at IS NOT NULL RETURNING "+userColumns, discordID))
+		if err == pgx.ErrNoRows {
+			return NotFoundf("deleted user with Discord ID %s not found", discordID)
+		}
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, EventUserUpdated, discordID, userEvent(ctx, user))
+	})
+	if err != nil {
+		return nil, txError("restore user", err)
+	}
+	return user, nil
+}
+
+// UpdateUserRole sets the role of an active user
+func (pg *PGClientImpl) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		var previous model.Role
+		err := tx.QueryRow(ctx, "SELECT role FROM users WHERE discord_id = $1 AND deleted_at IS NULL FOR UPDATE", discordID).Scan(&previous)
+		if err == pgx.ErrNoRows {
+			return NotFoundf("user with Discord ID %s not found", discordID)
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = scanUser(tx.QueryRow(ctx, "UPDATE users SET role = $2 WHERE discord_id = $1 RETURNING "+userColumns, discordID, role))
+		if err != nil || previous == role {
+			return err
+		}
+		return recordEvent(ctx, tx, EventRoleChanged, discordID, RoleChangedEvent{
+			DiscordID: discordID, PreviousRole: previous, Role: role, ActorDiscordID: actorDiscordID(ctx),
+		})
+	})
+	if err != nil {
+		return nil, txError("update user role", err)
+	}
+	return user, nil
+}
+
+// ReviewUser records the outcome of reviewing an active, pending user. Rejecting a user releases any
+// tag they hold.
+func (pg *PGClientImpl) ReviewUser(ctx context.Context, discordID string, review UserReview) (*model.User, error) {
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		current, err := scanUser(tx.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND status = 'PENDING' AND deleted_at IS NULL FOR UPDATE", discordID))
+		if err == pgx.ErrNoRows {
+			return NotFoundf("pending user with Discord ID %s not found", discordID)
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = scanUser(tx.QueryRow(ctx, "UPDATE users SET status = $2, rejection_reason = $3, reviewed_by = $4, reviewed_at = now(), "+
+			"tag_number = CASE WHEN $2 = 'REJECTED' THEN NULL ELSE tag_number END WHERE discord_id = $1 RETURNING "+userColumns,
+			discordID, review.Status, review.RejectionReason, review.ReviewedBy))
+		if err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, tx, EventUserUpdated, discordID, userEvent(ctx, user)); err != nil {
+			return err
+		}
+		return recordTagChange(ctx, tx, discordID, current.TagNumber, user.TagNumber, model.TagChangeReasonRelease)
+	})
+	if err != nil {
+		return nil, txError("review user", err)
+	}
+	return user, nil
+}
+
+// GetUserByTagNumber retrieves the active, approved user holding a bag tag
+func (pg *PGClientImpl) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	user, err := scanUser(pg.Pool.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE tag_number = $1 AND deleted_at IS NULL AND status = 'APPROVED'", tagNumber))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, NotFoundf("no user holds tag %d", tagNumber)
+		}
+		log.Printf("Error retrieving user by tag: %v", err)
+		return nil, fmt.Errorf("failed to get user by tag: %w", err)
+	}
+	return user, nil
+}
+
+// GetUsersByTagNumbers retrieves the active, approved holders of tags with a single ANY query. The
+// result lines up with tagNumbers, holding nil for each tag no such user holds.
+func (pg *PGClientImpl) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	rows, err := pg.Pool.Query(ctx, "SELECT "+userColumns+" FROM users WHERE tag_number = ANY($1) AND deleted_at IS NULL AND status = 'APPROVED'", tagNumbers)
+	if err != nil {
+		log.Printf("Error retrieving tag holders: %v", err)
+		return nil, fmt.Errorf("failed to get tag holders: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[int]*model.User, len(tagNumbers))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag holder: %w", err)
+		}
+		found[*user.TagNumber] = user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tag holders: %w", err)
+	}
+
+	users := make([]*model.User, len(tagNumbers))
+	for i, tagNumber := range tagNumbers {
+		users[i] = found[tagNumber]
+	}
+	return users, nil
+}
+
+// ClaimTag assigns an unclaimed bag tag to an active user, releasing any tag the user held before
+func (pg *PGClientImpl) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	var user *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		current, err := scanUser(tx.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND deleted_at IS NULL FOR UPDATE", discordID))
+		if err == pgx.ErrNoRows {
+			return NotFoundf("user with Discord ID %s not found", discordID)
+		}
+		if err != nil {
+			return err
+		}
+		if current.TagNumber != nil && *current.TagNumber == tagNumber {
+			user = current
+			return nil
+		}
+		if err := checkCanHoldTag(current); err != nil {
+			return err
+		}
+
+		var holder string
+		err = tx.QueryRow(ctx, "SELECT discord_id FROM users WHERE tag_number = $1 FOR UPDATE", tagNumber).Scan(&holder)
+		if err == nil {
+			return tagTakenError(tagNumber)
+		}
+		if err != pgx.ErrNoRows {
+			return err
+		}
+
+		user, err = scanUser(tx.QueryRow(ctx, "UPDATE users SET tag_number = $2 WHERE discord_id = $1 RETURNING "+userColumns, discordID, tagNumber))
+		if constraint, ok := uniqueViolation(err); ok && constraint == tagNumberConstraint {
+			return tagTakenError(tagNumber) // Lost a race with a concurrent claim
+		}
+		if err != nil {
+			return err
+		}
+		return recordTagChange(ctx, tx, discordID, current.TagNumber, user.TagNumber, model.TagChangeReasonClaim)
+	})
+	if err != nil {
+		return nil, txError("claim tag", err)
+	}
+	return user, nil
+}
+
+// SwapTags exchanges the tags of two active users in one statement, refusing the swap if either
+// user has no tag or their tag changed between the read and the update
+func (pg *PGClientImpl) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.User, *model.User, error) {
+	var userA, userB *model.User
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		tagA, err := currentTag(ctx, tx, discordIDA, "discordIDA")
+		if err != nil {
+			return err
+		}
+		tagB, err := currentTag(ctx, tx, discordIDB, "discordIDB")
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `UPDATE users SET tag_number = CASE discord_id WHEN $1 THEN $4 ELSE $3 END
+			WHERE deleted_at IS NULL AND ((discord_id = $1 AND tag_number = $3) OR (discord_id = $2 AND tag_number = $4))
+			RETURNING `+userColumns, discordIDA, discordIDB, tagA, tagB)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				return err
+			}
+			if user.DiscordID == discordIDA {
+				userA = user
+			} else {
+				userB = user
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if userA == nil || userB == nil {
+			return Conflictf("tags of %s and %s changed during the swap", discordIDA, discordIDB)
+		}
+		if err := recordTagChange(ctx, tx, discordIDA, &tagA, &tagB, model.TagChangeReasonSwap); err != nil {
+			return err
+		}
+		return recordTagChange(ctx, tx, discordIDB, &tagB, &tagA, model.TagChangeReasonSwap)
+	})
+	if err != nil {
+		return nil, nil, txError("swap tags", err)
+	}
+	return userA, userB, nil
+}
+
+// ReassignTags redistributes the participants' tags by finishing order in one transaction.
+// discordIDs must be distinct and every participant must be an active user holding a tag.
+func (pg *PGClientImpl) ReassignTags(ctx context.Context, discordIDs []string) ([]*model.TagAssignment, error) {
+	var assignments []*model.TagAssignment
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = ANY($1) AND deleted_at IS NULL FOR UPDATE", discordIDs)
+		if err != nil {
+			return err
+		}
+		current := make(map[string]*model.User, len(discordIDs))
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			current[user.DiscordID] = user
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		before, err := participantTags(discordIDs, current)
+		if err != nil {
+			return err
+		}
+		after := redistributeTags(before)
+
+		rows, err = tx.Query(ctx, `UPDATE users SET tag_number = v.tag_number
+			FROM unnest($1::text[], $2::int[]) AS v (discord_id, tag_number)
+			WHERE users.discord_id = v.discord_id
+			RETURNING `+qualifiedUserColumns, discordIDs, after)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		updated := make(map[string]*model.User, len(discordIDs))
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				return err
+			}
+			updated[user.DiscordID] = user
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i, id := range discordIDs {
+			if err := recordTagChange(ctx, tx, id, &before[i], &after[i], model.TagChangeReasonReassignment); err != nil {
+				return err
+			}
+			assignments = append(assignments, &model.TagAssignment{User: updated[id], PreviousTagNumber: before[i], TagNumber: after[i]})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, txError("reassign tags", err)
+	}
+	return assignments, nil
+}
+
+// GetTagHistoryByUser lists every change to a user's tag, oldest first
+func (pg *PGClientImpl) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	return pg.queryTagHistory(ctx, "SELECT "+tagChangeColumns+" FROM tag_history WHERE discord_id = $1 ORDER BY changed_at, id", discordID)
+}
+
+// GetTagHistoryByTag lists every change that gave out or took away a tag, oldest first
+func (pg *PGClientImpl) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	return pg.queryTagHistory(ctx, "SELECT "+tagChangeColumns+" FROM tag_history WHERE tag_number = $1 OR previous_tag_number = $1 ORDER BY changed_at, id", tagNumber)
+}
+
+// GetTagStandings reconstructs who held each tag at asOf from the tag history, ordered by tag.
+// Each user's most recent change at or before asOf gives the tag they held then.
+func (pg *PGClientImpl) GetTagStandings(ctx context.Context, asOf time.Time) ([]*model.TagStanding, error) {
+	rows, err := pg.Pool.Query(ctx, `SELECT tag_number, discord_id, changed_at FROM (
+			SELECT DISTINCT ON (discord_id) discord_id, tag_number, changed_at FROM tag_history
+			WHERE changed_at <= $1 ORDER BY discord_id, changed_at DESC, id DESC
+		) latest WHERE tag_number IS NOT NULL ORDER BY tag_number`, asOf)
+	if err != nil {
+		log.Printf("Error retrieving tag standings: %v", err)
+		return nil, fmt.Errorf("failed to get tag standings: %w", err)
+	}
+	defer rows.Close()
+
+	standings := []*model.TagStanding{}
+	for rows.Next() {
+		var standing model.TagStanding
+		if err := rows.Scan(&standing.TagNumber, &standing.DiscordID, &standing.HeldSince); err != nil {
+			return nil, fmt.Errorf("failed to scan tag standings: %w", err)
+		}
+		standings = append(standings, &standing)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tag standings: %w", err)
+	}
+	return standings, nil
+}
+
+// ListTagHolders returns up to limit active, approved users holding a tag above afterTag, ordered by tag.
+// It seeks on the tag_number index instead of using OFFSET, so deep pages stay cheap.
+func (pg *PGClientImpl) ListTagHolders(ctx context.Context, afterTag, limit int) ([]*model.User, error) {
+	rows, err := pg.Pool.Query(ctx, "SELECT "+userColumns+" FROM users WHERE tag_number > $1 AND deleted_at IS NULL AND status = 'APPROVED' ORDER BY tag_number LIMIT $2", afterTag, limit)
+	if err != nil {
+		log.Printf("Error listing tag holders: %v", err)
+		return nil, fmt.Errorf("failed to list tag holders: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag holder: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tag holders: %w", err)
+	}
+	return users, nil
+}
+
+// SearchUsers returns up to limit active, approved users whose name shares enough trigrams with query
+// to be a search candidate, most similar first. The <% operator can use users_name_trgm_idx; its
+// threshold is lowered from the default 0.6 so misspelled names still reach the Levenshtein scoring.
+func (pg *PGClientImpl) SearchUsers(ctx context.Context, query string, limit int) ([]*model.User, error) {
+	users := []*model.User{}
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', '0.3', true)"); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, "SELECT "+userColumns+" FROM users WHERE $1 <% name AND deleted_at IS NULL AND status = 'APPROVED' "+
+			"ORDER BY word_similarity($1, name) DESC, discord_id LIMIT $2", query, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, txError("search users", err)
+	}
+	return users, nil
+}
+
+// userOrderColumns maps each users ordering to its column; only these names are ever put into the SQL
+var userOrderColumns = map[model.UserOrderField]string{
+	model.UserOrderFieldDiscordID: "discord_id",
+	model.UserOrderFieldName:      "name",
+	model.UserOrderFieldCreatedAt: "created_at",
+}
+
+// likeEscaper escapes the LIKE wildcards in a literal pattern prefix
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// ListUsers returns a page of active users matching opts, seeking past opts.After on (sort column, discord_id).
+// Every value is passed as a query parameter.
+func (pg *PGClientImpl) ListUsers(ctx context.Context, opts ListUsersOptions) ([]*model.User, error) {
+	column, ok := userOrderColumns[opts.OrderBy.Field]
+	if !ok {
+		return nil, InvalidInputf("orderBy.field", "invalid order field %q", opts.OrderBy.Field)
+	}
+	direction, comparison := "ASC", ">"
+	if opts.OrderBy.Direction == model.OrderDirectionDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	var args []any
+	param := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	if f := opts.Filter; f != nil {
+		if role := f.Role.Value(); role != nil {
+			conditions = append(conditions, "role = "+param(*role))
+		}
+		if hasTag := f.HasTag.Value(); hasTag != nil {
+			if *hasTag {
+				conditions = append(conditions, "tag_number IS NOT NULL")
+			} else {
+				conditions = append(conditions, "tag_number IS NULL")
+			}
+		}
+		if prefix := f.NamePrefix.Value(); prefix != nil {
+			conditions = append(conditions, "name ILIKE "+param(likeEscaper.Replace(*prefix)+"%"))
+		}
+		if after := f.CreatedAfter.Value(); after != nil {
+			conditions = append(conditions, "created_at >= "+param(*after))
+		}
+		if before := f.CreatedBefore.Value(); before != nil {
+			conditions = append(conditions, "created_at < "+param(*before))
+		}
+		if status := f.Status.Value(); status != nil {
+			conditions = append(conditions, "status = "+param(*status))
+		}
+	}
+
+	order := "discord_id " + direction
+	if after := opts.After; after != nil {
+		switch column {
+		case "discord_id":
+			conditions = append(conditions, "discord_id "+comparison+" "+param(after.DiscordID))
+		case "name":
+			conditions = append(conditions, "(name, discord_id) "+comparison+" ("+param(after.Name)+", "+param(after.DiscordID)+")")
+		case "created_at":
+			conditions = append(conditions, "(created_at, discord_id) "+comparison+" ("+param(after.CreatedAt)+", "+param(after.DiscordID)+")")
+		}
+	}
+	if column != "discord_id" {
+		order = column + " " + direction + ", " + order
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + order + " LIMIT " + param(opts.Limit)
+	rows, err := pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+// queryTagHistory runs a query selecting tagChangeColumns and collects the rows
+func (pg *PGClientImpl) queryTagHistory(ctx context.Context, query string, args ...any) ([]*model.TagChange, error) {
+	rows, err := pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error retrieving tag history: %v", err)
+		return nil, fmt.Errorf("failed to get tag history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.TagChange{}
+	for rows.Next() {
+		var change model.TagChange
+		if err := rows.Scan(&change.DiscordID, &change.PreviousTagNumber, &change.TagNumber, &change.Reason, &change.ActorDiscordID, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag history: %w", err)
+		}
+		history = append(history, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tag history: %w", err)
+	}
+	return history, nil
+}
+
+// recordTagChange appends a tag_history row inside tx, unless the tag did not actually change
+func recordTagChange(ctx context.Context, tx pgx.Tx, discordID string, previous, current *int, reason model.TagChangeReason) error {
+	if equalTags(previous, current) {
+		return nil
+	}
+	actor := actorDiscordID(ctx)
+	_, err := tx.Exec(ctx, "INSERT INTO tag_history (discord_id, previous_tag_number, tag_number, reason, actor_discord_id) VALUES ($1, $2, $3, $4, $5)",
+		discordID, previous, current, reason, actor)
+	if err != nil {
+		return err
+	}
+	return recordEvent(ctx, tx, EventTagChanged, discordID, TagChangedEvent{
+		DiscordID: discordID, PreviousTagNumber: previous, TagNumber: current, Reason: reason, ActorDiscordID: actor,
+	})
+}
+
+// recordEvent appends a domain event to the outbox inside tx, so it is published only if tx commits
+func recordEvent(ctx context.Context, tx pgx.Tx, eventType EventType, discordID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO outbox (event_type, discord_id, payload) VALUES ($1, $2, $3)", eventType, discordID, string(data))
+	return err
+}
+
+// participantTags returns each participant's current tag in order, failing on missing, untagged or
+// unapproved users
+func participantTags(discordIDs []string, current map[string]*model.User) ([]int, error) {
+	tags := make([]int, len(discordIDs))
+	for i, id := range discordIDs {
+		user, ok := current[id]
+		if !ok {
+			return nil, NotFoundf("user with Discord ID %s not found", id)
+		}
+		if user.TagNumber == nil {
+			return nil, InvalidInputf(fmt.Sprintf("results[%d].discordID", i), "user with Discord ID %s has no tag", id)
+		}
+		if err := checkCanHoldTag(user); err != nil {
+			return nil, err
+		}
+		tags[i] = *user.TagNumber
+	}
+	return tags, nil
+}
+
+// currentTag reads the tag held by an active user, failing if the user has none or is not approved
+func currentTag(ctx context.Context, tx pgx.Tx, discordID, field string) (int, error) {
+	user, err := scanUser(tx.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE discord_id = $1 AND deleted_at IS NULL", discordID))
+	if err == pgx.ErrNoRows {
+		return 0, NotFoundf("user with Discord ID %s not found", discordID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if user.TagNumber == nil {
+		return 0, InvalidInputf(field, "user with Discord ID %s has no tag", discordID)
+	}
+	if err := checkCanHoldTag(user); err != nil {
+		return 0, err
+	}
+	return *user.TagNumber, nil
+}
+
+// withTx runs fn inside a transaction, committing only if fn succeeds
+func (pg *PGClientImpl) withTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// txError passes typed errors returned from a transaction through, and logs and wraps database failures
+func txError(action string, err error) error {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return err
+	}
+	log.Printf("Error trying to %s: %v", action, err)
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
+
+// uniqueViolation reports whether err is a PostgreSQL unique constraint violation, and on which constraint
+func uniqueViolation(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return pgErr.ConstraintName, true
+	}
+	return "", false
+}
+
+// CreateAPIKey stores a new API key under the hash of its secret and sets its CreatedAt
+func (pg *PGClientImpl) CreateAPIKey(ctx context.Context, key *model.APIKey, hash []byte) error {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+	err := pg.Pool.QueryRow(ctx, "INSERT INTO api_keys (id, name, key_hash, prefix, scopes, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at",
+		key.ID, key.Name, hash, key.Prefix, scopes, key.CreatedBy).Scan(&key.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash retrieves the unrevoked API key whose secret hashes to hash
+func (pg *PGClientImpl) GetAPIKeyByHash(ctx context.Context, hash []byte) (*model.APIKey, error) {
+	key, err := scanAPIKey(pg.Pool.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL", hash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, NotFoundf("API key not found")
+		}
+		log.Printf("Error retrieving API key: %v", err)
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the API keys oldest first, leaving out revoked keys unless includeRevoked is set
+func (pg *PGClientImpl) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*model.APIKey, error) {
+	rows, err := pg.Pool.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE $1 OR revoked_at IS NULL ORDER BY created_at, id", includeRevoked)
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stamps revoked_at on an unrevoked API key
+func (pg *PGClientImpl) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	key, err := scanAPIKey(pg.Pool.QueryRow(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL RETURNING "+apiKeyColumns, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, NotFoundf("active API key %s not found", id)
+		}
+		log.Printf("Error revoking API key: %v", err)
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// RelayEvents passes up to limit unpublished events to publish in outbox order and marks those
+// it accepted as published. It stops at the first event publish rejects, recording the failure
+// for a later retry, and returns that error along with how many events were published. While
+// another relay holds the outbox it publishes nothing.
+func (pg *PGClientImpl) RelayEvents(ctx context.Context, limit int, publish func(context.Context, Event) error) (int, error) {
+	var published []int64
+	var publishErr error
+	err := pg.withTx(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil || !locked {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "SELECT id, event_type, discord_id, payload, created_at FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT $1", limit)
+		if err != nil {
+			return err
+		}
+		var events []Event
+		for rows.Next() {
+			var event Event
+			var payload []byte
+			if err := rows.Scan(&event.ID, &event.Type, &event.DiscordID, &payload, &event.OccurredAt); err != nil {
+				rows.Close()
+				return err
+			}
+			event.Payload = payload
+			events = append(events, event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if publishErr = publish(ctx, event); publishErr != nil {
+				_, err := tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1", event.ID, publishErr.Error())
+				if err != nil {
+					return err
+				}
+				break
+			}
+			published = append(published, event.ID)
+		}
+		if len(published) == 0 {
+			return nil
+		}
+		_, err = tx.Exec(ctx, "UPDATE outbox SET published_at = now() WHERE id = ANY($1)", published)
+		return err
+	})
+	if err != nil {
+		return 0, txError("relay events", err)
+	}
+	if publishErr != nil {
+		return len(published), fmt.Errorf("failed to publish event: %w", publishErr)
+	}
+	return len(published), nil
+}
+
 // Close closes the database connection pool
 func (pg *PGClientImpl) Close(ctx context.Context) error {
 	pg.Pool.Close()
@@ -0,0 +1,687 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+// userRowColumns and createdAt build rows shaped like userColumns
+var (
+	userRowColumns = []string{"discord_id", "name", "tag_number", "role", "deleted", "created_at", "status", "rejection_reason", "reviewed_by", "reviewed_at"}
+	createdAt      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// approved and noReview fill the approval columns of a user row that was never reviewed
+var (
+	approved = model.ApprovalStatusApproved
+	noReview = (*string)(nil)
+)
+
+// apiKeyRowColumns builds rows shaped like apiKeyColumns
+var apiKeyRowColumns = []string{"id", "name", "prefix", "scopes", "created_by", "created_at", "revoked_at"}
+
+// expectEvent expects a domain event about discordID to be written to the outbox
+func expectEvent(mock pgxmock.PgxPoolIface, eventType service.EventType, discordID string) {
+	mock.ExpectExec("INSERT INTO outbox").
+		WithArgs(eventType, discordID, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+func newMockPGClient(t *testing.T) (*service.PGClientImpl, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create pgxmock pool: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+	return &service.PGClientImpl{Pool: mock}, mock
+}
+
+func TestPGClientImpl_GetUserByDiscordID(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	tag := 7
+	mock.ExpectQuery("SELECT discord_id, name, tag_number, role, deleted_at IS NOT NULL, created_at, status, rejection_reason, reviewed_by, reviewed_at FROM users").
+		WithArgs("12345", false).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", &tag, service.RoleEditor, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+
+	user, err := client.GetUserByDiscordID(context.Background(), "12345", false)
+	if err != nil {
+		t.Fatalf("GetUserByDiscordID() error = %v", err)
+	}
+	if user.TagNumber == nil || *user.TagNumber != tag {
+		t.Errorf("GetUserByDiscordID() TagNumber = %v, want %d", user.TagNumber, tag)
+	}
+	if user.Role != service.RoleEditor {
+		t.Errorf("GetUserByDiscordID() Role = %q, want %q", user.Role, service.RoleEditor)
+	}
+}
+
+func TestPGClientImpl_CreateUser(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	tag := 3
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users (.+) RETURNING created_at").
+		WithArgs("12345", "Test User", &tag, service.RoleRattler, model.ApprovalStatusPending).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+	expectEvent(mock, service.EventUserCreated, "12345")
+	mock.ExpectExec("INSERT INTO tag_history").
+		WithArgs("12345", (*int)(nil), &tag, model.TagChangeReasonClaim, (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectEvent(mock, service.EventTagChanged, "12345")
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	user := &model.User{DiscordID: "12345", Name: "Test User", TagNumber: &tag, Role: service.RoleRattler, Status: model.ApprovalStatusPending}
+	if err := client.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if !user.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreateUser() CreatedAt = %v, want %v", user.CreatedAt, createdAt)
+	}
+}
+
+func TestPGClientImpl_UpdateUser(t *testing.T) {
+	name := "Renamed"
+	tag := 4
+	tests := []struct {
+		name    string
+		input   model.UpdateUserInput
+		query   string
+		args    []any
+		newName string
+		updated *int
+		history bool
+	}{
+		{
+			name:    "Name_Only",
+			input:   model.UpdateUserInput{Name: graphql.OmittableOf(&name)},
+			query:   `UPDATE users SET name = \$2 WHERE`,
+			args:    []any{"12345", &name},
+			newName: name,
+			updated: &tag,
+		},
+		{
+			name:    "Clear_Tag",
+			input:   model.UpdateUserInput{TagNumber: graphql.OmittableOf[*int](nil)},
+			query:   `UPDATE users SET tag_number = \$2 WHERE`,
+			args:    []any{"12345", (*int)(nil)},
+			newName: "Test User",
+			history: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mock := newMockPGClient(t)
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+				WithArgs("12345").
+				WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("12345", "Test User", &tag, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+			mock.ExpectQuery(tt.query).
+				WithArgs(tt.args...).
+				WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("12345", tt.newName, tt.updated, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+			if tt.newName != "Test User" {
+				expectEvent(mock, service.EventUserUpdated, "12345")
+			}
+			if tt.history {
+				mock.ExpectExec("INSERT INTO tag_history").
+					WithArgs("12345", &tag, (*int)(nil), model.TagChangeReasonRelease, (*string)(nil)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				expectEvent(mock, service.EventTagChanged, "12345")
+			}
+			mock.ExpectCommit()
+			mock.ExpectRollback()
+
+			if _, err := client.UpdateUser(context.Background(), "12345", tt.input); err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPGClientImpl_DeleteUser(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	tag := 7
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE discord_id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs("12345").
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", &tag, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectQuery(`UPDATE users SET deleted_at = now\(\), tag_number = NULL WHERE discord_id = \$1`).
+		WithArgs("12345").
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", (*int)(nil), service.RoleRattler, true, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	expectEvent(mock, service.EventUserUpdated, "12345")
+	mock.ExpectExec("INSERT INTO tag_history").
+		WithArgs("12345", &tag, (*int)(nil), model.TagChangeReasonRelease, (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectEvent(mock, service.EventTagChanged, "12345")
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	user, err := client.DeleteUser(context.Background(), "12345")
+	if err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if !user.Deleted || user.TagNumber != nil {
+		t.Errorf("DeleteUser() = %+v, want deleted without a tag", user)
+	}
+}
+
+func TestPGClientImpl_UpdateUserRole(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT role FROM users WHERE discord_id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs("12345").
+		WillReturnRows(pgxmock.NewRows([]string{"role"}).AddRow(service.RoleRattler))
+	mock.ExpectQuery(`UPDATE users SET role = \$2 WHERE discord_id = \$1`).
+		WithArgs("12345", service.RoleEditor).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", (*int)(nil), service.RoleEditor, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	expectEvent(mock, service.EventRoleChanged, "12345")
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	user, err := client.UpdateUserRole(context.Background(), "12345", service.RoleEditor)
+	if err != nil {
+		t.Fatalf("UpdateUserRole() error = %v", err)
+	}
+	if user.Role != service.RoleEditor {
+		t.Errorf("UpdateUserRole() Role = %s, want %s", user.Role, service.RoleEditor)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT role FROM users").
+		WithArgs("missing").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+	if _, err := client.UpdateUserRole(context.Background(), "missing", service.RoleEditor); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("UpdateUserRole() missing user error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPGClientImpl_GetUserByDiscordID_NotFound(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	mock.ExpectQuery("SELECT (.+) FROM users").
+		WithArgs("missing", false).
+		WillReturnError(pgx.ErrNoRows)
+
+	user, err := client.GetUserByDiscordID(context.Background(), "missing", false)
+	if user != nil || !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetUserByDiscordID() = %v, %v, want nil, ErrNotFound", user, err)
+	}
+}
+
+func TestPGClientImpl_ClaimTag(t *testing.T) {
+	userRows := func(tag *int) *pgxmock.Rows {
+		return pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", tag, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil))
+	}
+
+	t.Run("Unclaimed_Tag", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		tag := 5
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+			WithArgs("12345").WillReturnRows(userRows(nil))
+		mock.ExpectQuery("SELECT discord_id FROM users WHERE tag_number = \\$1 FOR UPDATE").
+			WithArgs(tag).WillReturnError(pgx.ErrNoRows)
+		mock.ExpectQuery("UPDATE users SET tag_number = \\$2").
+			WithArgs("12345", tag).WillReturnRows(userRows(&tag))
+		mock.ExpectExec("INSERT INTO tag_history").
+			WithArgs("12345", (*int)(nil), &tag, model.TagChangeReasonClaim, (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		expectEvent(mock, service.EventTagChanged, "12345")
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		user, err := client.ClaimTag(context.Background(), "12345", tag)
+		if err != nil {
+			t.Fatalf("ClaimTag() error = %v", err)
+		}
+		if user.TagNumber == nil || *user.TagNumber != tag {
+			t.Errorf("ClaimTag() TagNumber = %v, want %d", user.TagNumber, tag)
+		}
+	})
+
+	t.Run("Taken_Tag", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1").
+			WithArgs("12345").WillReturnRows(userRows(nil))
+		mock.ExpectQuery("SELECT discord_id FROM users WHERE tag_number = \\$1").
+			WithArgs(5).WillReturnRows(pgxmock.NewRows([]string{"discord_id"}).AddRow("67890"))
+		mock.ExpectRollback()
+
+		_, err := client.ClaimTag(context.Background(), "12345", 5)
+		if code := service.ErrorCodeOf(err); code != service.CodeAlreadyExists {
+			t.Errorf("ClaimTag() error = %v, want ALREADY_EXISTS", err)
+		}
+	})
+
+	t.Run("Pending_User", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1").
+			WithArgs("12345").WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", (*int)(nil), service.RoleRattler, false, createdAt, model.ApprovalStatusPending, noReview, noReview, (*time.Time)(nil)))
+		mock.ExpectRollback()
+
+		_, err := client.ClaimTag(context.Background(), "12345", 5)
+		if code := service.ErrorCodeOf(err); code != service.CodeForbidden {
+			t.Errorf("ClaimTag() error = %v, want FORBIDDEN", err)
+		}
+	})
+}
+
+func TestPGClientImpl_SwapTags_Conflict(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	tagA, tagB := 1, 2
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1").
+		WithArgs("alice").WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("alice", "Alice", &tagA, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1").
+		WithArgs("bob").WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("bob", "Bob", &tagB, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	// Bob's tag changed after it was read, so only Alice's row matches
+	mock.ExpectQuery("UPDATE users SET tag_number = CASE discord_id").
+		WithArgs("alice", "bob", tagA, tagB).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("alice", "Alice", &tagB, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectRollback()
+
+	_, _, err := client.SwapTags(context.Background(), "alice", "bob")
+	if code := service.ErrorCodeOf(err); code != service.CodeConflict {
+		t.Errorf("SwapTags() error = %v, want CONFLICT", err)
+	}
+}
+
+func TestPGClientImpl_ReassignTags(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	one, two := 1, 2
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = ANY\\(\\$1\\)").
+		WithArgs([]string{"alice", "bob"}).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("bob", "Bob", &one, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("alice", "Alice", &two, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectQuery("UPDATE users SET tag_number = v.tag_number").
+		WithArgs([]string{"alice", "bob"}, []int{1, 2}).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("alice", "Alice", &one, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("bob", "Bob", &two, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectExec("INSERT INTO tag_history").
+		WithArgs("alice", &two, &one, model.TagChangeReasonReassignment, (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectEvent(mock, service.EventTagChanged, "alice")
+	mock.ExpectExec("INSERT INTO tag_history").
+		WithArgs("bob", &one, &two, model.TagChangeReasonReassignment, (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectEvent(mock, service.EventTagChanged, "bob")
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	assignments, err := client.ReassignTags(context.Background(), []string{"alice", "bob"})
+	if err != nil {
+		t.Fatalf("ReassignTags() error = %v", err)
+	}
+	if assignments[0].PreviousTagNumber != 2 || assignments[0].TagNumber != 1 {
+		t.Errorf("ReassignTags()[0] = %d -> %d, want 2 -> 1", assignments[0].PreviousTagNumber, assignments[0].TagNumber)
+	}
+}
+
+func TestPGClientImpl_GetTagStandings(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	asOf := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	heldSince := asOf.Add(-24 * time.Hour)
+
+	mock.ExpectQuery("SELECT DISTINCT ON \\(discord_id\\) (.+) FROM tag_history WHERE changed_at <= \\$1").
+		WithArgs(asOf).
+		WillReturnRows(pgxmock.NewRows([]string{"tag_number", "discord_id", "changed_at"}).
+			AddRow(1, "alice", heldSince).
+			AddRow(2, "bob", heldSince))
+
+	standings, err := client.GetTagStandings(context.Background(), asOf)
+	if err != nil {
+		t.Fatalf("GetTagStandings() error = %v", err)
+	}
+	if len(standings) != 2 || standings[0].DiscordID != "alice" || standings[1].TagNumber != 2 {
+		t.Errorf("GetTagStandings() = %v, want alice on 1 and bob on 2", standings)
+	}
+}
+
+func TestPGClientImpl_ListTagHolders(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	three, five := 3, 5
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE tag_number > \\$1 AND deleted_at IS NULL AND status = 'APPROVED' ORDER BY tag_number LIMIT \\$2").
+		WithArgs(2, 3).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("alice", "Alice", &three, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("bob", "Bob", &five, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+
+	users, err := client.ListTagHolders(context.Background(), 2, 3)
+	if err != nil {
+		t.Fatalf("ListTagHolders() error = %v", err)
+	}
+	if len(users) != 2 || users[0].DiscordID != "alice" || *users[1].TagNumber != five {
+		t.Errorf("ListTagHolders() = %v, want alice on 3 and bob on 5", users)
+	}
+}
+
+func TestPGClientImpl_SearchUsers(t *testing.T) {
+	client, mock := newMockPGClient(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('pg_trgm.word_similarity_threshold', '0.3', true\)`).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE \$1 <% name AND deleted_at IS NULL AND status = 'APPROVED' ORDER BY word_similarity\(\$1, name\) DESC, discord_id LIMIT \$2`).
+		WithArgs("jon", 100).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("jon", "Jon", (*int)(nil), service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("jonathan", "Jonathan Smith", (*int)(nil), service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	users, err := client.SearchUsers(context.Background(), "jon", 100)
+	if err != nil {
+		t.Fatalf("SearchUsers() error = %v", err)
+	}
+	if len(users) != 2 || users[0].DiscordID != "jon" || users[1].DiscordID != "jonathan" {
+		t.Errorf("SearchUsers() = %v, want jon then jonathan", users)
+	}
+}
+
+func TestPGClientImpl_ListUsers(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	role, prefix, hasTag := service.RoleEditor, "50%_off", true
+	after := &model.User{DiscordID: "alice", Name: "Alice", CreatedAt: createdAt}
+
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE deleted_at IS NULL AND role = \$1 AND tag_number IS NOT NULL AND name ILIKE \$2 `+
+		`AND \(name, discord_id\) < \(\$3, \$4\) ORDER BY name DESC, discord_id DESC LIMIT \$5`).
+		WithArgs(role, `50\%\_off%`, "Alice", "alice", 11).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).AddRow("bob", "Bob", (*int)(nil), role, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+
+	users, err := client.ListUsers(context.Background(), service.ListUsersOptions{
+		Filter: &model.UserFilter{
+			Role:       graphql.OmittableOf(&role),
+			HasTag:     graphql.OmittableOf(&hasTag),
+			NamePrefix: graphql.OmittableOf(&prefix),
+		},
+		OrderBy: model.UserOrder{Field: model.UserOrderFieldName, Direction: model.OrderDirectionDesc},
+		After:   after,
+		Limit:   11,
+	})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0].DiscordID != "bob" {
+		t.Errorf("ListUsers() = %v, want bob", users)
+	}
+}
+
+func TestPGClientImpl_ListUsers_ByDiscordID(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	from := createdAt.Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE deleted_at IS NULL AND created_at >= \$1 AND discord_id > \$2 ORDER BY discord_id ASC LIMIT \$3`).
+		WithArgs(from, "alice", 2).
+		WillReturnRows(pgxmock.NewRows(userRowColumns))
+
+	_, err := client.ListUsers(context.Background(), service.ListUsersOptions{
+		Filter:  &model.UserFilter{CreatedAfter: graphql.OmittableOf(&from)},
+		OrderBy: model.UserOrder{Field: model.UserOrderFieldDiscordID, Direction: model.OrderDirectionAsc},
+		After:   &model.User{DiscordID: "alice"},
+		Limit:   2,
+	})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+}
+
+func TestPGClientImpl_GetUsersByDiscordIDs(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	ids := []string{"carol", "alice", "missing", "alice"}
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = ANY\\(\\$1\\) AND deleted_at IS NULL").
+		WithArgs(ids).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("alice", "Alice", (*int)(nil), service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("carol", "Carol", (*int)(nil), service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+
+	users, err := client.GetUsersByDiscordIDs(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("GetUsersByDiscordIDs() error = %v", err)
+	}
+	if len(users) != len(ids) {
+		t.Fatalf("GetUsersByDiscordIDs() returned %d users, want %d", len(users), len(ids))
+	}
+	for i, id := range ids {
+		if id == "missing" {
+			if users[i] != nil {
+				t.Errorf("GetUsersByDiscordIDs()[%d] = %v, want nil", i, users[i])
+			}
+		} else if users[i] == nil || users[i].DiscordID != id {
+			t.Errorf("GetUsersByDiscordIDs()[%d] = %v, want %s", i, users[i], id)
+		}
+	}
+}
+
+func TestPGClientImpl_GetUsersByTagNumbers(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	one, three := 1, 3
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE tag_number = ANY\\(\\$1\\) AND deleted_at IS NULL AND status = 'APPROVED'").
+		WithArgs([]int{3, 2, 1}).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("alice", "Alice", &one, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)).
+			AddRow("carol", "Carol", &three, service.RoleRattler, false, createdAt, approved, noReview, noReview, (*time.Time)(nil)))
+
+	users, err := client.GetUsersByTagNumbers(context.Background(), []int{3, 2, 1})
+	if err != nil {
+		t.Fatalf("GetUsersByTagNumbers() error = %v", err)
+	}
+	if len(users) != 3 || users[0].DiscordID != "carol" || users[1] != nil || users[2].DiscordID != "alice" {
+		t.Errorf("GetUsersByTagNumbers() = %v, want [carol nil alice]", users)
+	}
+}
+
+func TestPGClientImpl_CreateAPIKey(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	admin := "admin"
+	key := &model.APIKey{ID: "keyID", Name: "bot", Prefix: "tcr_abcdefgh", Scopes: []model.APIKeyScope{model.APIKeyScopeUsersRead}, CreatedBy: &admin}
+	hash := []byte("hash")
+
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs("keyID", "bot", hash, "tcr_abcdefgh", []string{"USERS_READ"}, &admin).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+
+	if err := client.CreateAPIKey(context.Background(), key, hash); err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if !key.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreateAPIKey() CreatedAt = %v, want %v", key.CreatedAt, createdAt)
+	}
+}
+
+func TestPGClientImpl_GetAPIKeyByHash(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	hash := []byte("hash")
+
+	mock.ExpectQuery(`SELECT (.+) FROM api_keys WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs(hash).
+		WillReturnRows(pgxmock.NewRows(apiKeyRowColumns).
+			AddRow("keyID", "bot", "tcr_abcdefgh", []string{"USERS_READ", "TAGS_WRITE"}, (*string)(nil), createdAt, (*time.Time)(nil)))
+
+	key, err := client.GetAPIKeyByHash(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash() error = %v", err)
+	}
+	want := []model.APIKeyScope{model.APIKeyScopeUsersRead, model.APIKeyScopeTagsWrite}
+	if key.ID != "keyID" || len(key.Scopes) != 2 || key.Scopes[0] != want[0] || key.Scopes[1] != want[1] {
+		t.Errorf("GetAPIKeyByHash() = %+v, want keyID with scopes %v", key, want)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys").WithArgs([]byte("other")).WillReturnError(pgx.ErrNoRows)
+	if _, err := client.GetAPIKeyByHash(context.Background(), []byte("other")); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetAPIKeyByHash() unknown hash error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPGClientImpl_RevokeAPIKey(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	revokedAt := createdAt.Add(time.Hour)
+
+	mock.ExpectQuery(`UPDATE api_keys SET revoked_at = now\(\) WHERE id = \$1 AND revoked_at IS NULL`).
+		WithArgs("keyID").
+		WillReturnRows(pgxmock.NewRows(apiKeyRowColumns).
+			AddRow("keyID", "bot", "tcr_abcdefgh", []string{"USERS_READ"}, (*string)(nil), createdAt, &revokedAt))
+
+	key, err := client.RevokeAPIKey(context.Background(), "keyID")
+	if err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+	if key.RevokedAt == nil || !key.RevokedAt.Equal(revokedAt) {
+		t.Errorf("RevokeAPIKey() RevokedAt = %v, want %v", key.RevokedAt, revokedAt)
+	}
+
+	mock.ExpectQuery("UPDATE api_keys").WithArgs("keyID").WillReturnError(pgx.ErrNoRows)
+	if _, err := client.RevokeAPIKey(context.Background(), "keyID"); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("RevokeAPIKey() revoked key error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPGClientImpl_ReviewUser(t *testing.T) {
+	client, mock := newMockPGClient(t)
+	reason, reviewer := "Not a club member", "editor"
+	reviewedAt := createdAt.Add(time.Hour)
+	review := service.UserReview{Status: model.ApprovalStatusRejected, RejectionReason: &reason, ReviewedBy: &reviewer}
+
+	// Rejecting a user releases the tag they held
+	tag := 7
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE discord_id = \$1 AND status = 'PENDING' AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs("12345").
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", &tag, service.RoleRattler, false, createdAt, model.ApprovalStatusPending, noReview, noReview, (*time.Time)(nil)))
+	mock.ExpectQuery(`UPDATE users SET status = \$2, rejection_reason = \$3, reviewed_by = \$4, reviewed_at = now\(\), `+
+		`tag_number = CASE WHEN \$2 = 'REJECTED' THEN NULL ELSE tag_number END WHERE discord_id = \$1`).
+		WithArgs("12345", model.ApprovalStatusRejected, &reason, &reviewer).
+		WillReturnRows(pgxmock.NewRows(userRowColumns).
+			AddRow("12345", "Test User", (*int)(nil), service.RoleRattler, false, createdAt, model.ApprovalStatusRejected, &reason, &reviewer, &reviewedAt))
+	expectEvent(mock, service.EventUserUpdated, "12345")
+	mock.ExpectExec("INSERT INTO tag_history").
+		WithArgs("12345", &tag, (*int)(nil), model.TagChangeReasonRelease, (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectEvent(mock, service.EventTagChanged, "12345")
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	user, err := client.ReviewUser(context.Background(), "12345", review)
+	if err != nil {
+		t.Fatalf("ReviewUser() error = %v", err)
+	}
+	if user.Status != model.ApprovalStatusRejected || user.RejectionReason == nil || *user.RejectionReason != reason || user.TagNumber != nil {
+		t.Errorf("ReviewUser() = %+v, want rejected for %q without a tag", user, reason)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE discord_id = \\$1 AND status = 'PENDING'").
+		WithArgs("12345").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+	if _, err := client.ReviewUser(context.Background(), "12345", review); !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("ReviewUser() reviewed user error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPGClientImpl_RelayEvents(t *testing.T) {
+	eventRows := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"id", "event_type", "discord_id", "payload", "created_at"}).
+			AddRow(int64(1), service.EventUserCreated, "alice", []byte(`{"user":{"discordID":"alice"}}`), createdAt).
+			AddRow(int64(2), service.EventTagChanged, "alice", []byte(`{"discordID":"alice","tagNumber":1}`), createdAt)
+	}
+
+	t.Run("Published", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs(pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery(`SELECT (.+) FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT \$1`).WithArgs(10).WillReturnRows(eventRows())
+		mock.ExpectExec(`UPDATE outbox SET published_at = now\(\) WHERE id = ANY\(\$1\)`).
+			WithArgs([]int64{1, 2}).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		var types []service.EventType
+		published, err := client.RelayEvents(context.Background(), 10, func(ctx context.Context, event service.Event) error {
+			types = append(types, event.Type)
+			return nil
+		})
+		if err != nil || published != 2 {
+			t.Fatalf("RelayEvents() = %d, %v, want 2, nil", published, err)
+		}
+		if len(types) != 2 || types[0] != service.EventUserCreated || types[1] != service.EventTagChanged {
+			t.Errorf("RelayEvents() published %v, want UserCreated then TagChanged", types)
+		}
+	})
+
+	t.Run("Publish_Fails", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs(pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery(`SELECT (.+) FROM outbox`).WithArgs(10).WillReturnRows(eventRows())
+		mock.ExpectExec(`UPDATE outbox SET attempts = attempts \+ 1, last_error = \$2 WHERE id = \$1`).
+			WithArgs(int64(2), "broker down").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(`UPDATE outbox SET published_at = now\(\)`).
+			WithArgs([]int64{1}).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		published, err := client.RelayEvents(context.Background(), 10, func(ctx context.Context, event service.Event) error {
+			if event.ID == 2 {
+				return errors.New("broker down")
+			}
+			return nil
+		})
+		if err == nil || published != 1 {
+			t.Errorf("RelayEvents() = %d, %v, want 1 and an error", published, err)
+		}
+	})
+
+	t.Run("Locked_By_Another_Relay", func(t *testing.T) {
+		client, mock := newMockPGClient(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs(pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		published, err := client.RelayEvents(context.Background(), 10, func(ctx context.Context, event service.Event) error {
+			t.Errorf("RelayEvents() published %v while another relay held the outbox", event)
+			return nil
+		})
+		if err != nil || published != 0 {
+			t.Errorf("RelayEvents() = %d, %v, want 0, nil", published, err)
+		}
+	})
+}
@@ -0,0 +1,15 @@
+// service/roles.go
+
+package service
+
+import "github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+
+// Known user roles
+const (
+	RoleRattler = model.RoleRattler
+	RoleEditor  = model.RoleEditor
+	RoleAdmin   = model.RoleAdmin
+)
+
+// DefaultRole is assigned to users created without an explicit role
+const DefaultRole = RoleRattler
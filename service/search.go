@@ -0,0 +1,52 @@
+// service/search.go
+
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// maxSearchResults caps the limit argument of searchUsers
+const maxSearchResults = 25
+
+// minSearchScore drops names too different from the query to be what the player meant
+const minSearchScore = 0.5
+
+// partialMatchWeight scales the score of a query matching only the start of a name or word,
+// so that full matches rank first
+const partialMatchWeight = 0.9
+
+// searchCandidates is how many of the names the client finds most similar to the query SearchUsers scores
+const searchCandidates = 100
+
+// nameScore rates how closely name matches a search query from 0 to 1, ignoring case. Besides the
+// whole name, the query is compared with the start of the name and of each word in it, so partial
+// names such as "jon" for "Jonathan Smith" or "smi" for it score well.
+func nameScore(query, name string) float64 {
+	query, name = strings.ToLower(strings.TrimSpace(query)), strings.ToLower(name)
+	best := similarity(query, name)
+
+	length := utf8.RuneCountInString(query)
+	for _, word := range append([]string{name}, strings.Fields(name)...) {
+		runes := []rune(word)
+		if len(runes) < length {
+			continue
+		}
+		if score := partialMatchWeight * similarity(query, string(runes[:length])); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// similarity is one minus the Levenshtein distance between a and b, relative to the longer string
+func similarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein.ComputeDistance(a, b))/float64(longest)
+}
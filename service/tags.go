@@ -0,0 +1,33 @@
+// service/tags.go
+
+package service
+
+import (
+	"context"
+	"sort"
+)
+
+// redistributeTags takes the participants' tags in finishing order and returns the tag each
+// participant receives, so the best finisher gets the lowest tag among them
+func redistributeTags(tags []int) []int {
+	sorted := make([]int, len(tags))
+	copy(sorted, tags)
+	sort.Ints(sorted)
+	return sorted
+}
+
+// equalTags reports whether two optional tags hold the same value
+func equalTags(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// actorDiscordID returns the Discord ID of the caller making a change, or nil if unknown
+func actorDiscordID(ctx context.Context) *string {
+	if caller := CallerFromContext(ctx); caller != nil && caller.DiscordID != "" {
+		return &caller.DiscordID
+	}
+	return nil
+}
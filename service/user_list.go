@@ -0,0 +1,82 @@
+// service/user_list.go
+
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
+)
+
+// DefaultUserOrder is the ordering of the users query when none is given
+var DefaultUserOrder = model.UserOrder{Field: model.UserOrderFieldName, Direction: model.OrderDirectionAsc}
+
+// ListUsersOptions selects one page of active users for PGClient.ListUsers
+type ListUsersOptions struct {
+	Filter  *model.UserFilter // Nil matches every active user
+	OrderBy model.UserOrder
+	After   *model.User // Last user of the previous page; only DiscordID, Name and CreatedAt are used
+	Limit   int
+}
+
+// Apply returns the active users among users that the options select, in order. It is the
+// reference behaviour for PGClient implementations that do not query a database.
+func (o ListUsersOptions) Apply(users []*model.User) []*model.User {
+	selected := []*model.User{}
+	for _, user := range users {
+		if !user.Deleted && o.matches(user) && (o.After == nil || o.compare(user, o.After) > 0) {
+			selected = append(selected, user)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return o.compare(selected[i], selected[j]) < 0 })
+	if len(selected) > o.Limit {
+		selected = selected[:o.Limit]
+	}
+	return selected
+}
+
+// matches reports whether user passes every filter that is set
+func (o ListUsersOptions) matches(user *model.User) bool {
+	f := o.Filter
+	if f == nil {
+		return true
+	}
+	if role := f.Role.Value(); role != nil && user.Role != *role {
+		return false
+	}
+	if hasTag := f.HasTag.Value(); hasTag != nil && (user.TagNumber != nil) != *hasTag {
+		return false
+	}
+	if prefix := f.NamePrefix.Value(); prefix != nil && !strings.HasPrefix(strings.ToLower(user.Name), strings.ToLower(*prefix)) {
+		return false
+	}
+	if after := f.CreatedAfter.Value(); after != nil && user.CreatedAt.Before(*after) {
+		return false
+	}
+	if before := f.CreatedBefore.Value(); before != nil && !user.CreatedAt.Before(*before) {
+		return false
+	}
+	if status := f.Status.Value(); status != nil && user.Status != *status {
+		return false
+	}
+	return true
+}
+
+// compare orders a before b by the sort field, breaking ties by Discord ID, in the requested direction
+func (o ListUsersOptions) compare(a, b *model.User) int {
+	c := 0
+	switch o.OrderBy.Field {
+	case model.UserOrderFieldName:
+		c = strings.Compare(a.Name, b.Name)
+	case model.UserOrderFieldCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.DiscordID, b.DiscordID)
+	}
+	if o.OrderBy.Direction == model.OrderDirectionDesc {
+		return -c
+	}
+	return c
+}
@@ -4,16 +4,37 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
-	"github.com/jackc/pgx/v5"
 )
 
 // UserService interface defines methods for user operations
 type UserService interface {
-	GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error)
+	GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error)
+	GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error)
 	CreateUser(ctx context.Context, input model.UserInput) (*model.User, error)
+	UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error)
+	DeleteUser(ctx context.Context, discordID string) (*model.User, error)
+	RestoreUser(ctx context.Context, discordID string) (*model.User, error)
+	UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error)
+	ApproveUser(ctx context.Context, discordID string) (*model.User, error)
+	RejectUser(ctx context.Context, discordID string, reason string) (*model.User, error)
+	GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error)
+	GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error)
+	ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error)
+	SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.TagSwap, error)
+	ReassignTags(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error)
+	GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error)
+	GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error)
+	GetTagStandings(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error)
+	GetTagLeaderboard(ctx context.Context, first int, after *string) (*model.UserConnection, error)
+	ListUsers(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error)
 }
 
 // UserServiceImpl is the concrete implementation of UserService
@@ -26,47 +47,477 @@ func NewUserService(client PGClient) *UserServiceImpl {
 	return &UserServiceImpl{Client: client}
 }
 
-// CreateUser creates a new user in PostgreSQL
+// CreateUser creates a new user in PostgreSQL. Users created by anyone but an Editor or an
+// internal client start out pending approval.
 func (us *UserServiceImpl) CreateUser(ctx context.Context, input model.UserInput) (*model.User, error) {
 	// Validate input
-	if input.DiscordID == "" || input.Name == "" {
-		return nil, fmt.Errorf("DiscordID and Name are required")
+	if input.DiscordID == "" {
+		return nil, InvalidInputf("input.discordID", "DiscordID is required")
+	}
+	if input.Name == "" {
+		return nil, InvalidInputf("input.name", "Name is required")
+	}
+
+	role := DefaultRole
+	if r := input.Role.Value(); r != nil {
+		if !r.IsValid() {
+			return nil, InvalidInputf("input.role", "invalid role %q", *r)
+		}
+		if *r != DefaultRole && !CallerFromContext(ctx).HasRole(*r) {
+			return nil, Forbiddenf("cannot grant the %s role above your own", *r)
+		}
+		role = *r
 	}
 
-	// Check if the user already exists
-	user, err := us.Client.GetUserByDiscordID(ctx, input.DiscordID)
-	if err != nil && err != pgx.ErrNoRows { // Only proceed if error is not "no rows found"
-		return nil, fmt.Errorf("failed to check if user exists: %w", err)
+	tagNumber := input.TagNumber.Value()
+	if tagNumber != nil && *tagNumber <= 0 {
+		return nil, InvalidInputf("input.tagNumber", "TagNumber must be positive")
+	}
+
+	// Check if the user already exists, including soft-deleted users that could be restored
+	user, err := us.Client.GetUserByDiscordID(ctx, input.DiscordID, true)
+	if err != nil && !errors.Is(err, ErrNotFound) { // Only proceed if the user was not found
+		return nil, wrapClientError("failed to check if user exists", err)
 	}
 
 	if user != nil { // If user exists, return an error
-		return nil, fmt.Errorf("user with Discord ID %s already exists", input.DiscordID)
+		if user.Deleted {
+			return nil, AlreadyExistsf("user with Discord ID %s was deleted and must be restored", input.DiscordID)
+		}
+		return nil, AlreadyExistsf("user with Discord ID %s already exists", input.DiscordID)
 	}
 
 	// User does not exist, so we proceed to create a new user
 	newUser := &model.User{
 		DiscordID: input.DiscordID,
 		Name:      input.Name,
+		TagNumber: tagNumber,
+		Role:      role,
+		Status:    initialStatus(ctx),
+	}
+	if tagNumber != nil {
+		if err := checkCanHoldTag(newUser); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := us.Client.CreateUser(ctx, newUser); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, wrapClientError("failed to create user", err)
 	}
 
 	return newUser, nil
 }
 
-// GetUser ByDiscordID retrieves a user by Discord ID
-func (us *UserServiceImpl) GetUserByDiscordID(ctx context.Context, discordID string) (*model.User, error) {
+// GetUser ByDiscordID retrieves a user by Discord ID; only admins may include soft-deleted users.
+// A missing user is reported as an error matching ErrNotFound.
+func (us *UserServiceImpl) GetUserByDiscordID(ctx context.Context, discordID string, includeDeleted bool) (*model.User, error) {
+	// Validate input
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if includeDeleted && !CallerFromContext(ctx).IsAdmin() {
+		return nil, Forbiddenf("includeDeleted requires the %s role", RoleAdmin)
+	}
+
+	user, err := us.Client.GetUserByDiscordID(ctx, discordID, includeDeleted)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve user", err)
+	}
+
+	return user, nil
+}
+
+// GetUsersByDiscordIDs retrieves active users in one lookup. The result lines up with discordIDs,
+// holding nil for each ID that is missing, deleted or empty.
+func (us *UserServiceImpl) GetUsersByDiscordIDs(ctx context.Context, discordIDs []string) ([]*model.User, error) {
+	if len(discordIDs) == 0 {
+		return []*model.User{}, nil
+	}
+
+	users, err := us.Client.GetUsersByDiscordIDs(ctx, discordIDs)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve users", err)
+	}
+
+	return users, nil
+}
+
+// UpdateUser updates only the fields present in input. Callers other than the user themselves may
+// only update users whose role is not above their own.
+func (us *UserServiceImpl) UpdateUser(ctx context.Context, discordID string, input model.UpdateUserInput) (*model.User, error) {
 	// Validate input
 	if discordID == "" {
-		return nil, fmt.Errorf("DiscordID is required")
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if input.Name.IsSet() {
+		if name := input.Name.Value(); name == nil || *name == "" {
+			return nil, InvalidInputf("input.name", "Name cannot be cleared")
+		}
+	}
+	if tagNumber := input.TagNumber.Value(); tagNumber != nil && *tagNumber <= 0 {
+		return nil, InvalidInputf("input.tagNumber", "TagNumber must be positive")
+	}
+	if err := us.checkOutranks(ctx, discordID, "update"); err != nil {
+		return nil, err
+	}
+
+	user, err := us.Client.UpdateUser(ctx, discordID, input)
+	if err != nil {
+		return nil, wrapClientError("failed to update user", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser soft-deletes a user so it no longer appears in lookups. Callers other than the user
+// themselves may only delete users whose role is not above their own.
+func (us *UserServiceImpl) DeleteUser(ctx context.Context, discordID string) (*model.User, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if err := us.checkOutranks(ctx, discordID, "delete"); err != nil {
+		return nil, err
+	}
+
+	user, err := us.Client.DeleteUser(ctx, discordID)
+	if err != nil {
+		return nil, wrapClientError("failed to delete user", err)
+	}
+
+	return user, nil
+}
+
+// RestoreUser brings a soft-deleted user back into lookups
+func (us *UserServiceImpl) RestoreUser(ctx context.Context, discordID string) (*model.User, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
 	}
 
-	user, err := us.Client.GetUserByDiscordID(ctx, discordID)
+	user, err := us.Client.RestoreUser(ctx, discordID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve user: %w", err)
+		return nil, wrapClientError("failed to restore user", err)
 	}
 
 	return user, nil
 }
+
+// UpdateUserRole changes an active user's role. Callers may only grant roles at or below their own,
+// and only to users whose current role is not above theirs.
+func (us *UserServiceImpl) UpdateUserRole(ctx context.Context, discordID string, role model.Role) (*model.User, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if !role.IsValid() {
+		return nil, InvalidInputf("role", "invalid role %q", role)
+	}
+	caller := CallerFromContext(ctx)
+	if !caller.HasRole(role) {
+		return nil, Forbiddenf("cannot grant the %s role above your own", role)
+	}
+
+	user, err := us.Client.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve user", err)
+	}
+	if !caller.HasRole(user.Role) {
+		return nil, Forbiddenf("cannot change the role of a user with the %s role", user.Role)
+	}
+
+	user, err = us.Client.UpdateUserRole(ctx, discordID, role)
+	if err != nil {
+		return nil, wrapClientError("failed to update user role", err)
+	}
+
+	return user, nil
+}
+
+// checkOutranks fails unless the caller is the active user discordID or holds a role at least as
+// high as theirs. Users with the default role outrank nobody, so anyone already authorized may act on them.
+func (us *UserServiceImpl) checkOutranks(ctx context.Context, discordID, action string) error {
+	caller := CallerFromContext(ctx)
+	if caller != nil && caller.DiscordID == discordID {
+		return nil
+	}
+
+	user, err := us.Client.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return wrapClientError("failed to retrieve user", err)
+	}
+	if user.Role != DefaultRole && !caller.HasRole(user.Role) {
+		return Forbiddenf("cannot %s a user with the %s role", action, user.Role)
+	}
+	return nil
+}
+
+// ApproveUser approves a pending user, making them a full member
+func (us *UserServiceImpl) ApproveUser(ctx context.Context, discordID string) (*model.User, error) {
+	return us.reviewUser(ctx, discordID, UserReview{Status: model.ApprovalStatusApproved})
+}
+
+// RejectUser rejects a pending user, recording why
+func (us *UserServiceImpl) RejectUser(ctx context.Context, discordID string, reason string) (*model.User, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, InvalidInputf("reason", "Reason is required")
+	}
+	return us.reviewUser(ctx, discordID, UserReview{Status: model.ApprovalStatusRejected, RejectionReason: &reason})
+}
+
+// reviewUser records review on a pending user; only Editors may review sign-ups
+func (us *UserServiceImpl) reviewUser(ctx context.Context, discordID string, review UserReview) (*model.User, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if !CallerFromContext(ctx).HasRole(RoleEditor) {
+		return nil, Forbiddenf("reviewing sign-ups requires the %s role", RoleEditor)
+	}
+
+	user, err := us.Client.GetUserByDiscordID(ctx, discordID, false)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve user", err)
+	}
+	if user.Status != model.ApprovalStatusPending {
+		return nil, InvalidInputf("discordID", "user with Discord ID %s is already %s", discordID, user.Status)
+	}
+
+	review.ReviewedBy = actorDiscordID(ctx)
+	user, err = us.Client.ReviewUser(ctx, discordID, review)
+	if err != nil {
+		return nil, wrapClientError("failed to review user", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByTagNumber retrieves the user currently holding a bag tag
+func (us *UserServiceImpl) GetUserByTagNumber(ctx context.Context, tagNumber int) (*model.User, error) {
+	if tagNumber <= 0 {
+		return nil, InvalidInputf("tagNumber", "TagNumber must be positive")
+	}
+
+	user, err := us.Client.GetUserByTagNumber(ctx, tagNumber)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve user by tag", err)
+	}
+
+	return user, nil
+}
+
+// GetUsersByTagNumbers retrieves the active holders of tags in one lookup. The result lines up with
+// tagNumbers, holding nil for each tag nobody holds.
+func (us *UserServiceImpl) GetUsersByTagNumbers(ctx context.Context, tagNumbers []int) ([]*model.User, error) {
+	if len(tagNumbers) == 0 {
+		return []*model.User{}, nil
+	}
+
+	users, err := us.Client.GetUsersByTagNumbers(ctx, tagNumbers)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve tag holders", err)
+	}
+
+	return users, nil
+}
+
+// ClaimTag assigns an unclaimed bag tag to a user; taken tags are rejected with ALREADY_EXISTS
+func (us *UserServiceImpl) ClaimTag(ctx context.Context, discordID string, tagNumber int) (*model.User, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+	if tagNumber <= 0 {
+		return nil, InvalidInputf("tagNumber", "TagNumber must be positive")
+	}
+
+	user, err := us.Client.ClaimTag(ctx, discordID, tagNumber)
+	if err != nil {
+		return nil, wrapClientError("failed to claim tag", err)
+	}
+
+	return user, nil
+}
+
+// SwapTags exchanges the tags of two users in a single transaction
+func (us *UserServiceImpl) SwapTags(ctx context.Context, discordIDA, discordIDB string) (*model.TagSwap, error) {
+	if discordIDA == "" {
+		return nil, InvalidInputf("discordIDA", "DiscordID is required")
+	}
+	if discordIDB == "" {
+		return nil, InvalidInputf("discordIDB", "DiscordID is required")
+	}
+	if discordIDA == discordIDB {
+		return nil, InvalidInputf("discordIDB", "cannot swap tags with the same user")
+	}
+
+	userA, userB, err := us.Client.SwapTags(ctx, discordIDA, discordIDB)
+	if err != nil {
+		return nil, wrapClientError("failed to swap tags", err)
+	}
+
+	return &model.TagSwap{UserA: userA, UserB: userB}, nil
+}
+
+// ReassignTags redistributes the participants' existing tags by finishing order, best finisher first
+func (us *UserServiceImpl) ReassignTags(ctx context.Context, results []*model.RoundResultInput) ([]*model.TagAssignment, error) {
+	if len(results) == 0 {
+		return nil, InvalidInputf("results", "at least one result is required")
+	}
+
+	discordIDs := make([]string, len(results))
+	seen := make(map[string]bool, len(results))
+	for i, result := range results {
+		field := fmt.Sprintf("results[%d].discordID", i)
+		if result.DiscordID == "" {
+			return nil, InvalidInputf(field, "DiscordID is required")
+		}
+		if seen[result.DiscordID] {
+			return nil, InvalidInputf(field, "user with Discord ID %s is listed more than once", result.DiscordID)
+		}
+		seen[result.DiscordID] = true
+		discordIDs[i] = result.DiscordID
+	}
+
+	assignments, err := us.Client.ReassignTags(ctx, discordIDs)
+	if err != nil {
+		return nil, wrapClientError("failed to reassign tags", err)
+	}
+
+	return assignments, nil
+}
+
+// GetTagHistoryByUser lists every change to a user's tag, oldest first
+func (us *UserServiceImpl) GetTagHistoryByUser(ctx context.Context, discordID string) ([]*model.TagChange, error) {
+	if discordID == "" {
+		return nil, InvalidInputf("discordID", "DiscordID is required")
+	}
+
+	history, err := us.Client.GetTagHistoryByUser(ctx, discordID)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve tag history", err)
+	}
+
+	return history, nil
+}
+
+// GetTagHistoryByTag lists every change that gave out or took away a tag, oldest first
+func (us *UserServiceImpl) GetTagHistoryByTag(ctx context.Context, tagNumber int) ([]*model.TagChange, error) {
+	if tagNumber <= 0 {
+		return nil, InvalidInputf("tagNumber", "TagNumber must be positive")
+	}
+
+	history, err := us.Client.GetTagHistoryByTag(ctx, tagNumber)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve tag history", err)
+	}
+
+	return history, nil
+}
+
+// GetTagStandings lists the holder of each tag at asOf, or now if asOf is nil
+func (us *UserServiceImpl) GetTagStandings(ctx context.Context, asOf *time.Time) ([]*model.TagStanding, error) {
+	at := time.Now()
+	if asOf != nil {
+		at = *asOf
+	}
+
+	standings, err := us.Client.GetTagStandings(ctx, at)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve tag standings", err)
+	}
+
+	return standings, nil
+}
+
+// GetTagLeaderboard returns a page of active tag holders ordered by tag, starting after the after cursor
+func (us *UserServiceImpl) GetTagLeaderboard(ctx context.Context, first int, after *string) (*model.UserConnection, error) {
+	if err := validatePageSize(first); err != nil {
+		return nil, err
+	}
+	afterTag := 0
+	if after != nil {
+		tagNumber, err := decodeTagCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		afterTag = tagNumber
+	}
+
+	// Fetch one extra holder to learn whether another page follows
+	users, err := us.Client.ListTagHolders(ctx, afterTag, first+1)
+	if err != nil {
+		return nil, wrapClientError("failed to retrieve tag leaderboard", err)
+	}
+
+	return newUserConnection(users, first, func(user *model.User) string {
+		return encodeTagCursor(*user.TagNumber)
+	}), nil
+}
+
+// ListUsers returns a page of active users matching filter, ordered by orderBy or DefaultUserOrder
+func (us *UserServiceImpl) ListUsers(ctx context.Context, filter *model.UserFilter, orderBy *model.UserOrder, first int, after *string) (*model.UserConnection, error) {
+	if err := validatePageSize(first); err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		if role := filter.Role.Value(); role != nil && !role.IsValid() {
+			return nil, InvalidInputf("filter.role", "invalid role %q", *role)
+		}
+	}
+	opts := ListUsersOptions{Filter: filter, OrderBy: DefaultUserOrder, Limit: first + 1}
+	if orderBy != nil {
+		if !orderBy.Field.IsValid() || !orderBy.Direction.IsValid() {
+			return nil, InvalidInputf("orderBy", "invalid ordering %s %s", orderBy.Field, orderBy.Direction)
+		}
+		opts.OrderBy = *orderBy
+	}
+	if after != nil {
+		position, err := decodeUserCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		opts.After = position
+	}
+
+	// Limit asks for one extra user to learn whether another page follows
+	users, err := us.Client.ListUsers(ctx, opts)
+	if err != nil {
+		return nil, wrapClientError("failed to list users", err)
+	}
+
+	return newUserConnection(users, first, encodeUserCursor), nil
+}
+
+// SearchUsers ranks active, approved users by how closely their name matches query, best first, and returns
+// up to limit of them. Names scoring below minSearchScore are left out.
+func (us *UserServiceImpl) SearchUsers(ctx context.Context, query string, limit int) ([]*model.UserSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, InvalidInputf("query", "Query is required")
+	}
+	if limit < 1 || limit > maxSearchResults {
+		return nil, InvalidInputf("limit", "Limit must be between 1 and %d", maxSearchResults)
+	}
+
+	// The client narrows the users down to likely matches, which are then scored here
+	users, err := us.Client.SearchUsers(ctx, strings.TrimSpace(query), searchCandidates)
+	if err != nil {
+		return nil, wrapClientError("failed to search users", err)
+	}
+	var results []*model.UserSearchResult
+	for _, user := range users {
+		if score := nameScore(query, user.Name); score >= minSearchScore {
+			results = append(results, &model.UserSearchResult{User: user, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].User.Name < results[j].User.Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []*model.UserSearchResult{}
+	}
+	return results, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/graph/model"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/mocks"
 	"github.com/Black-And-White-Club/tcr-bot-user-service/service"
@@ -125,7 +126,7 @@ func TestUserServiceImpl_GetUserByDiscordID(t *testing.T) {
 					WithArgs(tt.discordID).
 					WillReturnError(pgx.ErrNoRows) // Use pgx.ErrNoRows }
 
-				_, err := userService.GetUserByDiscordID(context.Background(), tt.discordID)
+				_, err := userService.GetUserByDiscordID(context.Background(), tt.discordID, false)
 				if (err != nil) != tt.wantErr {
 					t.Errorf("User  ServiceImpl.GetUser ByDiscordID() error = %v, wantErr %v", err, tt.wantErr)
 				}
@@ -133,3 +134,163 @@ func TestUserServiceImpl_GetUserByDiscordID(t *testing.T) {
 		})
 	}
 }
+
+func TestUserServiceImpl_CreateUser_Role(t *testing.T) {
+	mockClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock client: %v", err)
+	}
+	defer mockClient.Close(context.Background())
+
+	userService := service.NewUserService(mockClient)
+
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "adminID", Role: service.RoleAdmin})
+	editorCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "editorID", Role: service.RoleEditor})
+
+	editor, admin := service.RoleEditor, service.RoleAdmin
+	unknown := model.Role("Overlord")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		role     *model.Role
+		wantRole model.Role
+		wantErr  bool
+	}{
+		{"Default_Role", context.Background(), nil, service.DefaultRole, false},
+		{"Explicit_Role", adminCtx, &editor, service.RoleEditor, false},
+		{"Own_Role", editorCtx, &editor, service.RoleEditor, false},
+		{"Above_Own_Role", editorCtx, &admin, "", true},
+		{"Anonymous_Explicit_Role", context.Background(), &editor, "", true},
+		{"Unknown_Role", adminCtx, &unknown, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userService.CreateUser(tt.ctx, model.UserInput{DiscordID: "newID", Name: "New User", Role: graphql.OmittableOf(tt.role)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UserServiceImpl.CreateUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Role != tt.wantRole {
+				t.Errorf("UserServiceImpl.CreateUser() role = %q, want %q", got.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestUserServiceImpl_UpdateUser(t *testing.T) {
+	mockClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock client: %v", err)
+	}
+	defer mockClient.Close(context.Background())
+
+	userService := service.NewUserService(mockClient)
+
+	name := "Renamed"
+	tests := []struct {
+		name      string
+		discordID string
+		input     model.UpdateUserInput
+		wantName  string
+		wantErr   bool
+	}{
+		{"Update_Name", "validID", model.UpdateUserInput{Name: graphql.OmittableOf(&name)}, name, false},
+		{"Clear_Tag_Keeps_Name", "validID", model.UpdateUserInput{TagNumber: graphql.OmittableOf[*int](nil)}, "Test User", false},
+		{"Null_Name", "validID", model.UpdateUserInput{Name: graphql.OmittableOf[*string](nil)}, "", true},
+		{"User_Not_Found", "unknownID", model.UpdateUserInput{Name: graphql.OmittableOf(&name)}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userService.UpdateUser(context.Background(), tt.discordID, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UserServiceImpl.UpdateUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Name != tt.wantName {
+				t.Errorf("UserServiceImpl.UpdateUser() name = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestUserServiceImpl_GetUserByDiscordID_IncludeDeleted(t *testing.T) {
+	mockClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock client: %v", err)
+	}
+	defer mockClient.Close(context.Background())
+
+	userService := service.NewUserService(mockClient)
+	adminCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "adminID", Role: service.RoleAdmin})
+	playerCtx := service.WithCaller(context.Background(), &service.Caller{DiscordID: "playerID", Role: service.RoleRattler})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr bool
+	}{
+		{"Admin_Sees_Deleted", adminCtx, false},
+		{"Player_Forbidden", playerCtx, true},
+		{"Anonymous_Forbidden", context.Background(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userService.GetUserByDiscordID(tt.ctx, "deletedID", true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UserServiceImpl.GetUserByDiscordID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Deleted {
+				t.Errorf("UserServiceImpl.GetUserByDiscordID() Deleted = false, want true")
+			}
+		})
+	}
+}
+
+func TestUserServiceImpl_RestoreUser(t *testing.T) {
+	mockClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock client: %v", err)
+	}
+	defer mockClient.Close(context.Background())
+
+	userService := service.NewUserService(mockClient)
+
+	if _, err := userService.RestoreUser(context.Background(), "deletedID"); err != nil {
+		t.Errorf("UserServiceImpl.RestoreUser() deleted user error = %v", err)
+	}
+	if _, err := userService.RestoreUser(context.Background(), "validID"); err == nil {
+		t.Errorf("UserServiceImpl.RestoreUser() active user expected error")
+	}
+}
+
+func TestUserServiceImpl_ErrorCodes(t *testing.T) {
+	mockClient, _, err := mocks.NewPGClientMock()
+	if err != nil {
+		t.Fatalf("failed to create mock client: %v", err)
+	}
+	defer mockClient.Close(context.Background())
+
+	userService := service.NewUserService(mockClient)
+
+	_, err = userService.CreateUser(context.Background(), model.UserInput{DiscordID: "validID", Name: "Test User"})
+	if code := service.ErrorCodeOf(err); code != service.CodeAlreadyExists {
+		t.Errorf("CreateUser() existing user code = %v, want %v", code, service.CodeAlreadyExists)
+	}
+
+	_, err = userService.CreateUser(context.Background(), model.UserInput{DiscordID: "newID"})
+	var svcErr *service.Error
+	if !errors.As(err, &svcErr) || svcErr.Code != service.CodeInvalidInput || svcErr.Field != "input.name" {
+		t.Errorf("CreateUser() missing name error = %v, want INVALID_INPUT on input.name", err)
+	}
+
+	_, err = userService.GetUserByDiscordID(context.Background(), "unknownID", false)
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Errorf("GetUserByDiscordID() unknown user error = %v, want ErrNotFound", err)
+	}
+
+	_, err = userService.DeleteUser(context.Background(), "unknownID")
+	if code := service.ErrorCodeOf(err); code != service.CodeNotFound {
+		t.Errorf("DeleteUser() unknown user code = %v, want %v", code, service.CodeNotFound)
+	}
+}